@@ -1,25 +1,57 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"sort"
 	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/Chirpy/internal/auth"
 	"github.com/Chirpy/internal/database"
+	"github.com/Chirpy/internal/entities"
+	"github.com/Chirpy/internal/media"
+	"github.com/Chirpy/internal/moderation"
+	"github.com/Chirpy/internal/views"
 	"github.com/google/uuid"
 )
 
 type apiConfig struct {
 	fileserverHits atomic.Int32
+	db             *sql.DB
 	dbQueries      *database.Queries
 	platform       string
 	svrToken       string
 	apiToken       string
+	adminKey       string
+
+	chirpEditWindow  time.Duration
+	chirpDeleteGrace time.Duration
+
+	mediaStore media.Storage
+	views      *views.Counter
+	moderators *moderation.Pipeline
+
+	bannedWords bannedWordCache
+}
+
+// withTx runs fn against a transaction-scoped Queries, committing when fn
+// returns nil and rolling back otherwise.
+func (cfg *apiConfig) withTx(ctx context.Context, fn func(q *database.Queries) error) error {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(cfg.dbQueries.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
@@ -54,10 +86,26 @@ func (cfg *apiConfig) handlerReset(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, "Reset")
 }
 
+// usernameIndex is the unique index on users.username.
+const usernameIndex = "idx_users_username"
+
+var (
+	errInvalidUsername = fmt.Errorf("username must be %d to %d letters, digits or underscores",
+		entities.MinUsernameLength, entities.MaxUsernameLength)
+	errUsernameTaken = errors.New("Username is taken")
+)
+
+// defaultUsername names users who sign up without choosing a username. It is
+// random rather than based on the email so it gives nothing away.
+func defaultUsername() string {
+	return "user_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+}
+
 func (cfg *apiConfig) handleUsers(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
 		Password string `json:"password"`
 		Email    string `json:"email"`
+		Username string `json:"username"`
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -72,13 +120,26 @@ func (cfg *apiConfig) handleUsers(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusBadRequest, err.Error())
 	}
 
+	username := defaultUsername()
+	if params.Username != "" {
+		var ok bool
+		if username, ok = entities.NormalizeUsername(params.Username); !ok {
+			respondWithError(w, http.StatusBadRequest, errInvalidUsername.Error())
+			return
+		}
+	}
+
 	password, err := auth.HashPassword(params.Password)
 	if err != nil {
 		return
 	}
 
 	createUser, err := cfg.dbQueries.CreateUser(r.Context(),
-		database.CreateUserParams{Email: params.Email, HashedPassword: password})
+		database.CreateUserParams{Email: params.Email, HashedPassword: password, Username: username})
+	if isUniqueViolationOn(err, usernameIndex) {
+		respondWithError(w, http.StatusConflict, errUsernameTaken.Error())
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -87,82 +148,111 @@ func (cfg *apiConfig) handleUsers(w http.ResponseWriter, r *http.Request) {
 		user{ID: createUser.ID, CreatedAt: createUser.CreatedAt.String(),
 			UpdatedAt:   createUser.UpdatedAt.String(),
 			Email:       createUser.Email,
+			Username:    createUser.Username,
 			IsChirpyRed: createUser.IsChirpyRed,
+			Role:        createUser.Role,
 		})
 }
 
+func chirpFromDB(dbChirp database.Chirp) chirp {
+	returnChirp := chirp{
+		ID:           dbChirp.ID,
+		CreatedAt:    dbChirp.CreatedAt.String(),
+		UpdatedAt:    dbChirp.UpdatedAt.String(),
+		Body:         dbChirp.Body,
+		UserID:       dbChirp.UserID,
+		ReplyCount:   dbChirp.ReplyCount,
+		RechirpCount: dbChirp.RechirpCount,
+		QuoteCount:   dbChirp.QuoteCount,
+		LikeCount:    dbChirp.LikeCount,
+		ViewCount:    dbChirp.ViewCount,
+		Deleted:      dbChirp.DeletedAt.Valid,
+		Visibility:   dbChirp.Visibility,
+		Sensitive:    dbChirp.Sensitive,
+	}
+	if returnChirp.Deleted {
+		// Deleted chirps only surface as placeholders inside threads.
+		returnChirp.Body = ""
+	} else {
+		// Entities are written by chirpEntities; a row that fails to decode
+		// just goes out without them.
+		_ = json.Unmarshal(dbChirp.Entities, &returnChirp.Entities)
+		returnChirp.ContentWarning = dbChirp.ContentWarning
+	}
+	if dbChirp.ParentChirpID.Valid {
+		returnChirp.ParentChirpID = &dbChirp.ParentChirpID.UUID
+	}
+	if dbChirp.RootChirpID.Valid {
+		returnChirp.RootChirpID = &dbChirp.RootChirpID.UUID
+	}
+	if dbChirp.RechirpOfID.Valid {
+		returnChirp.RechirpOfID = &dbChirp.RechirpOfID.UUID
+	}
+	if dbChirp.QuoteOfID.Valid {
+		returnChirp.QuoteOfID = &dbChirp.QuoteOfID.UUID
+	}
+	if dbChirp.EditedAt.Valid {
+		returnChirp.EditedAt = dbChirp.EditedAt.Time.String()
+	}
+	if dbChirp.PublishAt.Valid {
+		returnChirp.PublishAt = dbChirp.PublishAt.Time.String()
+	}
+	return returnChirp
+}
+
 func (cfg *apiConfig) handleGetChirps(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	// Check for author_id query parameter
-	authorIDParam := r.URL.Query().Get("author_id")
-	if authorIDParam != "" {
-		// Handle filtering by author ID
-		parsedAuthorID, err := uuid.Parse(authorIDParam)
-		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid author ID format")
-			return
-		}
-
-		chirps, err := cfg.dbQueries.GetChirpsByUserId(r.Context(), parsedAuthorID)
-		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, err.Error())
-			return
-		}
-
-		returnChirps := make([]chirp, len(chirps))
-		for i, v := range chirps {
-			returnChirps[i] = chirp{
-				ID:        v.ID,
-				CreatedAt: v.CreatedAt.String(),
-				UpdatedAt: v.UpdatedAt.String(),
-				Body:      v.Body,
-				UserID:    v.UserID,
-			}
-		}
-		respondWithJSON(w, http.StatusOK, returnChirps)
+	listQuery, err := parseChirpListQuery(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-
-	chirps, err := cfg.dbQueries.GetChirps(r.Context())
+	viewer, err := cfg.optionalUser(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	listQuery.params.ViewerID = viewer
+	preference, err := cfg.sensitiveMediaPreference(r.Context(), viewer)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
 		return
 	}
-	tmpChirps := make([]chirp, len(chirps))
-	for i, v := range chirps {
-		tmpChirps[i] = chirp{
-			ID:        v.ID,
-			CreatedAt: v.CreatedAt.String(),
-			UpdatedAt: v.UpdatedAt.String(),
-			Body:      v.Body,
-			UserID:    v.UserID}
+	listQuery.params.HideSensitive = preference == sensitiveMediaHide
+
+	chirps, err := cfg.listChirps(r.Context(), listQuery)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
 	}
 
-	sortParam := r.URL.Query().Get("sort")
-	returnChirps := tmpChirps
-	sortParam = strings.ToLower(sortParam)
-	if sortParam != "" && sortParam != "asc" && sortParam != "desc" {
-		respondWithError(w, http.StatusBadRequest, "Invalid sort parameter")
+	page, err := cfg.newChirpPage(r.Context(), viewer, chirps, listQuery.limit)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
 		return
 	}
-	switch sortParam {
-	case "asc":
-		sort.Slice(tmpChirps, func(i, j int) bool {
-			return tmpChirps[i].CreatedAt < tmpChirps[j].CreatedAt
-		})
-		break
-	case "desc":
-		sort.Slice(tmpChirps, func(i, j int) bool {
-			return tmpChirps[i].CreatedAt > tmpChirps[j].CreatedAt
-		})
-		break
+	if authorID, ok := listQuery.profileAuthor(); ok {
+		firstPage := !listQuery.params.AfterCreatedAt.Valid
+		if err := cfg.addPinnedChirp(r.Context(), viewer, authorID, firstPage, &page); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+			return
+		}
 	}
-
-	respondWithJSON(w, http.StatusOK, returnChirps)
+	page.Chirps = applySensitivePreference(page.Chirps, preference, viewer)
+	cfg.recordViews(r, viewer, page.Chirps)
+	respondWithJSON(w, http.StatusOK, page)
 }
+
 func (cfg *apiConfig) handleChirps(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Body string `json:"body"`
+		Body           string      `json:"body"`
+		ParentChirpID  *uuid.UUID  `json:"parent_chirp_id"`
+		PublishAt      *time.Time  `json:"publish_at"`
+		MediaIDs       []uuid.UUID `json:"media_ids"`
+		Poll           *pollInput  `json:"poll"`
+		Visibility     string      `json:"visibility"`
+		ContentWarning string      `json:"content_warning"`
+		Sensitive      bool        `json:"sensitive"`
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -170,14 +260,9 @@ func (cfg *apiConfig) handleChirps(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusBadRequest, "No body")
 		return
 	}
-	bearerToken, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, err.Error())
-		return
-	}
-	userUuid, err := auth.ValidateJWT(bearerToken, cfg.svrToken)
+	userUuid, err := cfg.activeUser(r)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		respondWithActiveUserError(w, err)
 		return
 	}
 
@@ -189,43 +274,276 @@ func (cfg *apiConfig) handleChirps(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// fmt.Println(params)
-	if len(params.Body) > 140 {
-		respondWithError(w, http.StatusBadRequest, "Chirp is too long")
+	prepared, err := cfg.prepareNewChirp(r.Context(), userUuid, newChirpInput{
+		Body:           params.Body,
+		ParentChirpID:  params.ParentChirpID,
+		PublishAt:      params.PublishAt,
+		MediaIDs:       params.MediaIDs,
+		Poll:           params.Poll,
+		Visibility:     params.Visibility,
+		ContentWarning: params.ContentWarning,
+		Sensitive:      params.Sensitive,
+	})
+	if err != nil {
+		respondWithNewChirpError(w, err)
 		return
 	}
 
-	removeProfanity(&params.Body)
-	createChirp, err := cfg.dbQueries.CreateChirp(r.Context(),
-		database.CreateChirpParams{Body: params.Body, UserID: userUuid})
+	var createChirp database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		createChirp, err = insertChirp(r.Context(), q, prepared)
+		return err
+	})
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Something went wrong")
+		respondWithNewChirpError(w, err)
 		return
 	}
-	respondWithJSON(w, http.StatusCreated,
-		chirp{
-			ID:        createChirp.ID,
-			CreatedAt: createChirp.CreatedAt.String(),
-			UpdatedAt: createChirp.UpdatedAt.String(),
-			Body:      createChirp.Body,
-			UserID:    createChirp.UserID})
+	returnChirp := chirpFromDB(createChirp)
+	err = cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userUuid, Valid: true}, []*chirp{&returnChirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, returnChirp)
+
+}
+
+var (
+	errParentNotFound = errors.New("Parent chirp not found")
+	errReplyToRechirp = errors.New("Reply to the original chirp instead of a rechirp")
+	errTooManyMedia   = fmt.Errorf("A chirp can have at most %d attachments", maxChirpMedia)
+	errDuplicateMedia = errors.New("Each attachment can only be used once")
+	errMediaNotFound  = errors.New("Media not found")
+)
+
+// newChirpInput is what a caller asks to post, either directly through
+// handleChirps or by publishing a draft.
+type newChirpInput struct {
+	Body           string
+	ParentChirpID  *uuid.UUID
+	PublishAt      *time.Time
+	MediaIDs       []uuid.UUID
+	Poll           *pollInput
+	Visibility     string
+	ContentWarning string
+	Sensitive      bool
+}
 
+// preparedChirp is a validated chirp waiting to be written by insertChirp.
+type preparedChirp struct {
+	params    database.CreateChirpParams
+	mediaIDs  []uuid.UUID
+	poll      *preparedPoll
+	moderated moderation.Result
 }
 
-func removeProfanity(chirp *string) {
-	profane := []string{"kerfuffle", "sharbert", "fornax"}
-	newChirp := *chirp
+// prepareNewChirp runs the validation every new chirp goes through and
+// resolves the reply and entity fields, without writing anything.
+func (cfg *apiConfig) prepareNewChirp(ctx context.Context, userID uuid.UUID, input newChirpInput) (preparedChirp, error) {
+	publishAt, err := parsePublishAt(input.PublishAt, time.Now())
+	if err != nil {
+		return preparedChirp{}, err
+	}
+	if publishAt.Valid && input.ParentChirpID != nil {
+		return preparedChirp{}, errScheduledReply
+	}
+	chirpPoll, err := preparePoll(input.Poll, publishAt, time.Now())
+	if err != nil {
+		return preparedChirp{}, err
+	}
+	visibility, err := parseVisibility(input.Visibility)
+	if err != nil {
+		return preparedChirp{}, err
+	}
+	contentWarning, sensitive, err := prepareContentWarning(input.ContentWarning, input.Sensitive)
+	if err != nil {
+		return preparedChirp{}, err
+	}
+	limit, err := cfg.chirpLengthLimit(ctx, userID)
+	if err != nil {
+		return preparedChirp{}, err
+	}
+	if err := checkChirpLength(input.Body, limit); err != nil {
+		return preparedChirp{}, err
+	}
+	if len(input.MediaIDs) > maxChirpMedia {
+		return preparedChirp{}, errTooManyMedia
+	}
+	seenMedia := make(map[uuid.UUID]bool, len(input.MediaIDs))
+	for _, id := range input.MediaIDs {
+		if seenMedia[id] {
+			return preparedChirp{}, errDuplicateMedia
+		}
+		seenMedia[id] = true
+	}
 
-	c := strings.Split(newChirp, " ")
-	for i, v := range c {
-		for _, word := range profane {
-			if strings.ToLower(v) == word {
-				c[i] = strings.Repeat("*", 4)
+	// Moderators see every text of the chirp at once and may change any of
+	// them, so the results replace what was validated above.
+	toModerate := moderation.Chirp{AuthorID: userID, Body: input.Body, ContentWarning: contentWarning}
+	if input.ParentChirpID != nil {
+		toModerate.ParentID = uuid.NullUUID{UUID: *input.ParentChirpID, Valid: true}
+	}
+	if chirpPoll != nil {
+		toModerate.PollOptions = chirpPoll.labels
+	}
+	moderated, err := cfg.moderators.Run(ctx, toModerate)
+	if err != nil {
+		return preparedChirp{}, err
+	}
+	body, contentWarning := moderated.Chirp.Body, moderated.Chirp.ContentWarning
+	if chirpPoll != nil {
+		chirpPoll.labels = moderated.Chirp.PollOptions
+	}
+	chirpEntities, err := cfg.chirpEntities(ctx, body)
+	if err != nil {
+		return preparedChirp{}, err
+	}
+
+	createParams := database.CreateChirpParams{
+		Body:           body,
+		UserID:         userID,
+		Entities:       chirpEntities,
+		PublishAt:      publishAt,
+		Visibility:     visibility,
+		ContentWarning: contentWarning,
+		Sensitive:      sensitive,
+	}
+	if input.ParentChirpID != nil {
+		parent, err := cfg.dbQueries.GetChirpById(ctx, database.GetChirpByIdParams{
+			ID:       *input.ParentChirpID,
+			ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return preparedChirp{}, errParentNotFound
 			}
+			return preparedChirp{}, err
+		}
+		if parent.RechirpOfID.Valid {
+			return preparedChirp{}, errReplyToRechirp
+		}
+		createParams.ParentChirpID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		createParams.RootChirpID = parent.RootChirpID
+		if !parent.RootChirpID.Valid {
+			createParams.RootChirpID = uuid.NullUUID{UUID: parent.ID, Valid: true}
 		}
 	}
-	newChirp = strings.Join(c, " ")
+	return preparedChirp{params: createParams, mediaIDs: input.MediaIDs, poll: chirpPoll, moderated: moderated}, nil
+}
 
-	*chirp = newChirp
+// insertChirp writes a prepared chirp along with its hashtags, attachments,
+// poll, review flag and the reply count on its parent.
+func insertChirp(ctx context.Context, q *database.Queries, prepared preparedChirp) (database.Chirp, error) {
+	createParams := prepared.params
+	createChirp, err := q.CreateChirp(ctx, createParams)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if err := flagHeldChirp(ctx, q, createChirp.ID, prepared.moderated); err != nil {
+		return database.Chirp{}, err
+	}
+	if len(prepared.mediaIDs) > 0 {
+		attached, err := q.AttachMedia(ctx, database.AttachMediaParams{
+			ChirpID: createChirp.ID,
+			Ids:     prepared.mediaIDs,
+			UserID:  createChirp.UserID,
+		})
+		if err != nil {
+			return database.Chirp{}, err
+		}
+		if attached != int64(len(prepared.mediaIDs)) {
+			return database.Chirp{}, errMediaNotFound
+		}
+	}
+	if prepared.poll != nil {
+		if err := insertPoll(ctx, q, createChirp.ID, prepared.poll); err != nil {
+			return database.Chirp{}, err
+		}
+	}
+	if err := saveChirpHashtags(ctx, q, createChirp); err != nil {
+		return database.Chirp{}, err
+	}
+	if createParams.ParentChirpID.Valid {
+		if err := q.IncrementReplyCount(ctx, createParams.ParentChirpID.UUID); err != nil {
+			return database.Chirp{}, err
+		}
+	}
+	return createChirp, nil
+}
+
+func respondWithNewChirpError(w http.ResponseWriter, err error) {
+	var tooLong *chirpTooLongError
+	if errors.As(err, &tooLong) {
+		respondWithChirpTooLong(w, tooLong)
+		return
+	}
+	var rejected *moderation.RejectedError
+	if errors.As(err, &rejected) {
+		respondWithError(w, http.StatusBadRequest, rejected.Reason)
+		return
+	}
+	switch err {
+	case errParentNotFound:
+		respondWithError(w, http.StatusNotFound, err.Error())
+	case errReplyToRechirp, errScheduledReply, errPublishAtPast, errPublishAtTooFar,
+		errTooManyMedia, errDuplicateMedia, errMediaNotFound,
+		errPollOptionCount, errPollOptionLength, errPollOptionDuplicate, errPollDuration,
+		errInvalidVisibility, errContentWarningLength:
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+	}
+}
+
+const (
+	defaultChirpLengthLimit = 140
+	chirpyRedLengthLimit    = 280
+)
+
+// chirpTooLongError carries the numbers a client needs to tell the author
+// how far over their limit a chirp is.
+type chirpTooLongError struct {
+	Length int
+	Limit  int
+}
+
+func (e *chirpTooLongError) Error() string {
+	return fmt.Sprintf("Chirp is too long: %d characters, the limit is %d", e.Length, e.Limit)
+}
+
+func respondWithChirpTooLong(w http.ResponseWriter, tooLong *chirpTooLongError) {
+	type errorResp struct {
+		Error     string `json:"error"`
+		Length    int    `json:"length"`
+		MaxLength int    `json:"max_length"`
+	}
+	respondWithJSON(w, http.StatusBadRequest, errorResp{
+		Error:     tooLong.Error(),
+		Length:    tooLong.Length,
+		MaxLength: tooLong.Limit,
+	})
+}
+
+// chirpLengthLimit is the longest chirp userID's plan allows.
+func (cfg *apiConfig) chirpLengthLimit(ctx context.Context, userID uuid.UUID) (int, error) {
+	author, err := cfg.dbQueries.GetUserById(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if author.IsChirpyRed {
+		return chirpyRedLengthLimit, nil
+	}
+	return defaultChirpLengthLimit, nil
+}
+
+// checkChirpLength applies the length limit every chirp body goes through.
+// Length is counted with entities.Length.
+func checkChirpLength(body string, limit int) error {
+	if length := entities.Length(body); length > limit {
+		return &chirpTooLongError{Length: length, Limit: limit}
+	}
+	return nil
 }
 
 func (cfg *apiConfig) handleGetChirpByID(w http.ResponseWriter, r *http.Request) {
@@ -242,8 +560,15 @@ func (cfg *apiConfig) handleGetChirpByID(w http.ResponseWriter, r *http.Request)
 		respondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	viewer, err := cfg.optionalUser(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
 
-	dbChirp, err := cfg.dbQueries.GetChirpById(r.Context(), parsedChirpID)
+	// Chirps hidden from the viewer are reported as missing, never as
+	// forbidden, so their existence does not leak.
+	dbChirp, err := cfg.dbQueries.GetChirpById(r.Context(), database.GetChirpByIdParams{ID: parsedChirpID, ViewerID: viewer})
 	if err != nil {
 		if err == sql.ErrNoRows {
 			respondWithError(w, http.StatusNotFound, "Chirp not found")
@@ -253,16 +578,46 @@ func (cfg *apiConfig) handleGetChirpByID(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	returnChirp := chirp{
-		ID:        dbChirp.ID,
-		CreatedAt: dbChirp.CreatedAt.String(),
-		UpdatedAt: dbChirp.UpdatedAt.String(),
-		Body:      dbChirp.Body,
-		UserID:    dbChirp.UserID,
+	returnChirp := chirpFromDB(dbChirp)
+	err = cfg.hydrateChirps(r.Context(), viewer, []*chirp{&returnChirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
 	}
+	cfg.recordViews(r, viewer, []chirp{returnChirp})
 	respondWithJSON(w, http.StatusOK, returnChirp)
 }
 
+var errNotChirpOwner = errors.New("Forbidden")
+
+// ownedChirp loads a chirp on behalf of a user who wants to change it.
+// Chirps the user cannot see come back as sql.ErrNoRows and other users'
+// chirps as errNotChirpOwner.
+func (cfg *apiConfig) ownedChirp(ctx context.Context, chirpID, userID uuid.UUID) (database.Chirp, error) {
+	foundChirp, err := cfg.dbQueries.GetChirpById(ctx, database.GetChirpByIdParams{
+		ID:       chirpID,
+		ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if foundChirp.UserID != userID {
+		return database.Chirp{}, errNotChirpOwner
+	}
+	return foundChirp, nil
+}
+
+func respondWithOwnedChirpError(w http.ResponseWriter, err error) {
+	switch err {
+	case sql.ErrNoRows:
+		respondWithError(w, http.StatusNotFound, "Chirp not found")
+	case errNotChirpOwner:
+		respondWithError(w, http.StatusForbidden, err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+	}
+}
+
 func (cfg *apiConfig) handleChirpDelete(w http.ResponseWriter, r *http.Request) {
 	chirpID := r.PathValue("chirpID")
 
@@ -272,30 +627,41 @@ func (cfg *apiConfig) handleChirpDelete(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	foundChirp, err := cfg.dbQueries.GetChirpById(r.Context(), parsedChirpID)
+	caller, err := cfg.activeAccount(r)
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, err.Error())
+		respondWithActiveUserError(w, err)
 		return
 	}
+	userUuid := caller.ID
 
-	bearerToken, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, err.Error())
+	foundChirp, err := cfg.ownedChirp(r.Context(), parsedChirpID, userUuid)
+	// Moderators may delete chirps they cannot see, such as followers-only or
+	// scheduled ones, so a miss is retried without the visibility filter.
+	if (err == errNotChirpOwner || err == sql.ErrNoRows) && hasRole(caller.Role, roleModerator) {
+		actor := uuid.NullUUID{UUID: userUuid, Valid: true}
+		err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+			return deleteChirpAsModerator(r.Context(), q, actor, parsedChirpID)
+		})
+		if err != nil {
+			respondWithOwnedChirpError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
 		return
 	}
-	userUuid, err := auth.ValidateJWT(bearerToken, cfg.svrToken)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		respondWithOwnedChirpError(w, err)
 		return
 	}
 
-	if foundChirp.UserID != userUuid {
-		respondWithError(w, http.StatusForbidden, "Forbidden")
-		return
-	}
-
-	deleteChirpParams := database.DeleteChirpParams{ID: parsedChirpID, UserID: userUuid}
-	err = cfg.dbQueries.DeleteChirp(r.Context(), deleteChirpParams)
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		deleteChirpParams := database.DeleteChirpParams{ID: parsedChirpID, UserID: userUuid}
+		deleted, err := q.DeleteChirp(r.Context(), deleteChirpParams)
+		if err != nil || deleted == 0 {
+			return err
+		}
+		return adjustReferenceCounts(r.Context(), q, foundChirp, false)
+	})
 	if err != nil {
 		respondWithError(w, http.StatusForbidden, err.Error())
 		return
@@ -310,6 +676,8 @@ func (cfg *apiConfig) handleUserUpdate(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		// Username is optional; the current one is kept when it is empty.
+		Username string `json:"username"`
 	}
 	w.Header().Set("Content-Type", "application/json")
 	if r.Body == nil {
@@ -323,15 +691,9 @@ func (cfg *apiConfig) handleUserUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	bearerToken, err := auth.GetBearerToken(r.Header)
+	userUuid, err := cfg.activeUser(r)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, err.Error())
-		return
-	}
-
-	userUuid, err := auth.ValidateJWT(bearerToken, cfg.svrToken)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		respondWithActiveUserError(w, err)
 		return
 	}
 
@@ -355,7 +717,19 @@ func (cfg *apiConfig) handleUserUpdate(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusUnauthorized, err.Error())
 	}
 	userParams := database.UpdateUserByIdParams{ID: userUuid, Email: params.Email, HashedPassword: hashedPassword}
+	if params.Username != "" {
+		username, ok := entities.NormalizeUsername(params.Username)
+		if !ok {
+			respondWithError(w, http.StatusBadRequest, errInvalidUsername.Error())
+			return
+		}
+		userParams.Username = sql.NullString{String: username, Valid: true}
+	}
 	updatedUser, err := cfg.dbQueries.UpdateUserById(r.Context(), userParams)
+	if isUniqueViolationOn(err, usernameIndex) {
+		respondWithError(w, http.StatusConflict, errUsernameTaken.Error())
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, err.Error())
 		return
@@ -365,9 +739,11 @@ func (cfg *apiConfig) handleUserUpdate(w http.ResponseWriter, r *http.Request) {
 		CreatedAt:    updatedUser.CreatedAt.String(),
 		UpdatedAt:    updatedUser.UpdatedAt.String(),
 		Email:        updatedUser.Email,
+		Username:     updatedUser.Username,
 		Token:        "",
 		RefreshToken: "",
 		IsChirpyRed:  updatedUser.IsChirpyRed,
+		Role:         updatedUser.Role,
 	}
 	respondWithJSON(w, http.StatusOK, userResponse)
 }
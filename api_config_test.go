@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckChirpLength_CountsCharactersNotBytes(t *testing.T) {
+	// 140 emoji are 560 bytes but only 140 characters.
+	body := strings.Repeat("🐦", defaultChirpLengthLimit)
+	if err := checkChirpLength(body, defaultChirpLengthLimit); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+func TestCheckChirpLength_TooLong(t *testing.T) {
+	body := strings.Repeat("a", defaultChirpLengthLimit+1)
+
+	err := checkChirpLength(body, defaultChirpLengthLimit)
+	var tooLong *chirpTooLongError
+	if !errors.As(err, &tooLong) {
+		t.Fatalf("Expected a chirpTooLongError, got %v", err)
+	}
+	if tooLong.Length != defaultChirpLengthLimit+1 || tooLong.Limit != defaultChirpLengthLimit {
+		t.Errorf("Expected %d/%d, got %d/%d", defaultChirpLengthLimit+1, defaultChirpLengthLimit, tooLong.Length, tooLong.Limit)
+	}
+
+	if err := checkChirpLength(body, chirpyRedLengthLimit); err != nil {
+		t.Errorf("Expected the Chirpy Red limit to allow it, got %v", err)
+	}
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
@@ -30,8 +33,93 @@ func (cfg *apiConfig) handleRevoke(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func (cfg *apiConfig) mkJWT(userID uuid.UUID, expiresIn time.Duration) (string, error) {
-	return auth.MakeJWT(userID, cfg.svrToken, expiresIn)
+// authenticatedUser returns the user ID carried by the request's bearer JWT.
+func (cfg *apiConfig) authenticatedUser(r *http.Request) (uuid.UUID, error) {
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	return auth.ValidateJWT(bearerToken, cfg.svrToken)
+}
+
+var (
+	errUnauthorized     = errors.New("Unauthorized")
+	errAccountSuspended = errors.New("Account suspended")
+)
+
+// activeUser is authenticatedUser for requests that change anything. It also
+// checks the account, so a suspended user's access token stops working at
+// once rather than when it expires. Errors are errUnauthorized,
+// errAccountSuspended or a lookup failure; see respondWithActiveUserError.
+func (cfg *apiConfig) activeUser(r *http.Request) (uuid.UUID, error) {
+	account, err := cfg.activeAccount(r)
+	return account.ID, err
+}
+
+// activeAccount is activeUser returning the caller's whole row, for handlers
+// that go on to check the caller's role. Roles are always taken from here:
+// the role in the JWT is only a hint for clients and goes stale when a user
+// is promoted or demoted.
+func (cfg *apiConfig) activeAccount(r *http.Request) (database.User, error) {
+	userID, err := cfg.authenticatedUser(r)
+	if err != nil {
+		return database.User{}, errUnauthorized
+	}
+	return cfg.loadActiveAccount(r.Context(), userID)
+}
+
+// loadActiveAccount returns errAccountSuspended for suspended users and
+// errUnauthorized for users that no longer exist.
+func (cfg *apiConfig) loadActiveAccount(ctx context.Context, userID uuid.UUID) (database.User, error) {
+	found, err := cfg.dbQueries.GetUserById(ctx, userID)
+	if err == sql.ErrNoRows {
+		return database.User{}, errUnauthorized
+	}
+	if err != nil {
+		return database.User{}, err
+	}
+	if found.SuspendedAt.Valid {
+		return database.User{}, errAccountSuspended
+	}
+	return found, nil
+}
+
+func respondWithActiveUserError(w http.ResponseWriter, err error) {
+	switch err {
+	case errUnauthorized:
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+	case errAccountSuspended:
+		respondWithError(w, http.StatusForbidden, err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+	}
+}
+
+// optionalUser identifies the caller when a bearer token is present. Anonymous
+// requests get an invalid NullUUID; a bad token is still an error.
+func (cfg *apiConfig) optionalUser(r *http.Request) (uuid.NullUUID, error) {
+	if r.Header.Get("Authorization") == "" {
+		return uuid.NullUUID{}, nil
+	}
+	userID, err := cfg.authenticatedUser(r)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	return uuid.NullUUID{UUID: userID, Valid: true}, nil
+}
+
+// hasAdminKey reports whether the request carries the ADMIN_KEY. The check
+// fails closed when no key is configured.
+func (cfg *apiConfig) hasAdminKey(r *http.Request) bool {
+	if cfg.adminKey == "" {
+		return false
+	}
+	requestApiKey, err := auth.GetAPIKey(r.Header)
+	return err == nil && requestApiKey == cfg.adminKey
+}
+
+func (cfg *apiConfig) mkJWT(userID uuid.UUID, role string, expiresIn time.Duration) (string, error) {
+	return auth.MakeJWT(userID, role, cfg.svrToken, expiresIn)
 }
 
 func (cfg *apiConfig) handleRefresh(w http.ResponseWriter, r *http.Request) {
@@ -58,7 +146,18 @@ func (cfg *apiConfig) handleRefresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jwt, err := cfg.mkJWT(token.UserID, time.Duration(60*60)*time.Second)
+	// The new token carries the user's current role, so role changes reach
+	// a user at their next refresh.
+	tokenUser, err := cfg.dbQueries.GetUserById(r.Context(), token.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if tokenUser.SuspendedAt.Valid {
+		respondWithError(w, http.StatusForbidden, errAccountSuspended.Error())
+		return
+	}
+	jwt, err := cfg.mkJWT(tokenUser.ID, tokenUser.Role, time.Duration(60*60)*time.Second)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, err.Error())
 		return
@@ -103,7 +202,11 @@ func (cfg *apiConfig) handleLogin(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusUnauthorized, "Incorrect email or password")
 		return
 	}
-	jwt, err := cfg.mkJWT(user.ID, time.Duration(JWTExpiresInSeconds)*time.Second)
+	if user.SuspendedAt.Valid {
+		respondWithError(w, http.StatusForbidden, errAccountSuspended.Error())
+		return
+	}
+	jwt, err := cfg.mkJWT(user.ID, user.Role, time.Duration(JWTExpiresInSeconds)*time.Second)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "JWT creation error")
 		return
@@ -126,9 +229,11 @@ func (cfg *apiConfig) handleLogin(w http.ResponseWriter, r *http.Request) {
 		CreatedAt:    user.CreatedAt.String(),
 		UpdatedAt:    user.UpdatedAt.String(),
 		Email:        user.Email,
+		Username:     user.Username,
 		Token:        jwt,
 		RefreshToken: refresh.Token,
 		IsChirpyRed:  user.IsChirpyRed,
+		Role:         user.Role,
 	}
 	respondWithJSON(w, http.StatusOK, response)
 
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+const maxBannedWordLength = 50
+
+// How a moderator can settle a chirp held for review.
+const (
+	reviewApprove = "approve"
+	reviewHide    = "hide"
+)
+
+var (
+	errBannedWordFormat = fmt.Errorf("word must be a single word of 1 to %d characters", maxBannedWordLength)
+	errBannedWordAction = errors.New("action must be mask, reject or flag")
+	errReviewDecision   = errors.New("decision must be approve or hide")
+	errChirpNotHeld     = errors.New("Chirp is not held for review")
+)
+
+type bannedWord struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt string    `json:"created_at"`
+	UpdatedAt string    `json:"updated_at"`
+	Word      string    `json:"word"`
+	Action    string    `json:"action"`
+}
+
+type bannedWordParameters struct {
+	Word   string `json:"word"`
+	Action string `json:"action"`
+}
+
+func bannedWordFromDB(w database.BannedWord) bannedWord {
+	return bannedWord{
+		ID:        w.ID,
+		CreatedAt: w.CreatedAt.String(),
+		UpdatedAt: w.UpdatedAt.String(),
+		Word:      w.Word,
+		Action:    w.Action,
+	}
+}
+
+// validate normalizes the word to lower case and defaults the action to
+// mask. Words are matched one at a time, so they cannot contain spaces.
+func (params *bannedWordParameters) validate() error {
+	params.Word = strings.ToLower(strings.TrimSpace(params.Word))
+	if params.Word == "" || utf8.RuneCountInString(params.Word) > maxBannedWordLength ||
+		strings.IndexFunc(params.Word, unicode.IsSpace) >= 0 {
+		return errBannedWordFormat
+	}
+	switch params.Action {
+	case "":
+		params.Action = bannedWordMask
+	case bannedWordMask, bannedWordReject, bannedWordFlag:
+	default:
+		return errBannedWordAction
+	}
+	return nil
+}
+
+// decodeBannedWord reads and validates a banned word request body, writing
+// the error response itself when it fails.
+func decodeBannedWord(w http.ResponseWriter, r *http.Request) (bannedWordParameters, bool) {
+	decoder := json.NewDecoder(r.Body)
+	params := bannedWordParameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Something went wrong")
+		return params, false
+	}
+	if err := params.validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return params, false
+	}
+	return params, true
+}
+
+func (cfg *apiConfig) handleGetBannedWords(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if _, ok := cfg.actorWithRole(r, roleAdmin); !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	words, err := cfg.dbQueries.ListBannedWords(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	response := make([]bannedWord, 0, len(words))
+	for _, word := range words {
+		response = append(response, bannedWordFromDB(word))
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) handleCreateBannedWord(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if _, ok := cfg.actorWithRole(r, roleAdmin); !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	params, ok := decodeBannedWord(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := cfg.dbQueries.CreateBannedWord(r.Context(), database.CreateBannedWordParams{
+		Word:   params.Word,
+		Action: params.Action,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "Word is already banned")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	cfg.invalidateBannedWords()
+	respondWithJSON(w, http.StatusCreated, bannedWordFromDB(created))
+}
+
+func (cfg *apiConfig) handleUpdateBannedWord(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if _, ok := cfg.actorWithRole(r, roleAdmin); !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	parsedWordID, err := uuid.Parse(r.PathValue("wordID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	params, ok := decodeBannedWord(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := cfg.dbQueries.UpdateBannedWord(r.Context(), database.UpdateBannedWordParams{
+		ID:     parsedWordID,
+		Word:   params.Word,
+		Action: params.Action,
+	})
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Banned word not found")
+		case isUniqueViolation(err):
+			respondWithError(w, http.StatusConflict, "Word is already banned")
+		default:
+			respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		}
+		return
+	}
+	cfg.invalidateBannedWords()
+	respondWithJSON(w, http.StatusOK, bannedWordFromDB(updated))
+}
+
+func (cfg *apiConfig) handleDeleteBannedWord(w http.ResponseWriter, r *http.Request) {
+	if _, ok := cfg.actorWithRole(r, roleAdmin); !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	parsedWordID, err := uuid.Parse(r.PathValue("wordID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	deleted, err := cfg.dbQueries.DeleteBannedWord(r.Context(), parsedWordID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Banned word not found")
+		return
+	}
+	cfg.invalidateBannedWords()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetFlaggedChirps pages through chirps the moderation chain held for
+// review, most recently flagged first, with the reasons they were held.
+func (cfg *apiConfig) handleGetFlaggedChirps(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	query := r.URL.Query()
+
+	if _, ok := cfg.actorWithRole(r, roleModerator); !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	listParams := database.ListFlaggedChirpsParams{RowLimit: int32(limit + 1)}
+	cursorParam := query.Get("cursor")
+	if cursorParam != "" {
+		cursor, err := pagination.DecodeCursor(cursorParam)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		listParams.AfterFlaggedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		listParams.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	chirps, err := cfg.dbQueries.ListFlaggedChirps(r.Context(), listParams)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	// Like bookmarks, the cursor follows a time other than created_at.
+	var nextCursor string
+	if len(chirps) > limit {
+		chirps = chirps[:limit]
+		last := chirps[limit-1]
+		nextCursor = pagination.Cursor{CreatedAt: last.FlaggedAt.Time, ID: last.ID}.Encode()
+	}
+	page, err := cfg.newChirpPage(r.Context(), uuid.NullUUID{}, chirps, limit)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	page.NextCursor = nextCursor
+	for i := range page.Chirps {
+		page.Chirps[i].FlagReason = chirps[i].FlagReason
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// handleReviewFlaggedChirp settles a held chirp. Approving it clears the flag;
+// hiding it removes it the way a resolved report would. Either way the
+// decision and the reasons it was held for go in the moderation log.
+func (cfg *apiConfig) handleReviewFlaggedChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Decision string `json:"decision"`
+		Note     string `json:"note"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	actor, ok := cfg.actorWithRole(r, roleModerator)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Something went wrong")
+		return
+	}
+	if params.Decision != reviewApprove && params.Decision != reviewHide {
+		respondWithError(w, http.StatusBadRequest, errReviewDecision.Error())
+		return
+	}
+
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		held, err := q.GetChirpForModeration(r.Context(), parsedChirpID)
+		if err != nil {
+			return err
+		}
+		if !held.FlaggedAt.Valid || held.DeletedAt.Valid {
+			return errChirpNotHeld
+		}
+		action := moderationActionApproveChirp
+		if params.Decision == reviewHide {
+			action = resolutionHideChirp
+			if err := q.HideChirp(r.Context(), held.ID); err != nil {
+				return err
+			}
+			if err := adjustReferenceCounts(r.Context(), q, held, false); err != nil {
+				return err
+			}
+		} else if err := q.ClearChirpFlag(r.Context(), held.ID); err != nil {
+			return err
+		}
+		note := "held for " + held.FlagReason
+		if trimmed := strings.TrimSpace(params.Note); trimmed != "" {
+			note += ": " + trimmed
+		}
+		return q.CreateModerationLogEntry(r.Context(), database.CreateModerationLogEntryParams{
+			ActorID: actor,
+			Action:  action,
+			ChirpID: uuid.NullUUID{UUID: held.ID, Valid: true},
+			UserID:  uuid.NullUUID{UUID: held.UserID, Valid: true},
+			Note:    note,
+		})
+	})
+	switch {
+	case err == sql.ErrNoRows:
+		respondWithError(w, http.StatusNotFound, "Chirp not found")
+		return
+	case err == errChirpNotHeld:
+		respondWithError(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
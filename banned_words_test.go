@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestFlagChirp_AddsNewReasons(t *testing.T) {
+	cfg := newTestConfig(t)
+	ctx := context.Background()
+	author, _ := createTestUser(t, cfg, roleUser)
+	chirp := createTestChirp(t, cfg, author.ID, "held twice", visibilityPublic)
+
+	for _, reason := range []string{"banned word", "duplicate; banned word"} {
+		if err := cfg.dbQueries.FlagChirp(ctx, database.FlagChirpParams{ID: chirp.ID, FlagReason: reason}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	found, err := cfg.dbQueries.GetChirpForModeration(ctx, chirp.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.FlagReason != "banned word; duplicate" {
+		t.Errorf("got flag reason %q, want %q", found.FlagReason, "banned word; duplicate")
+	}
+}
+
+func TestHandleReviewFlaggedChirp_Approve(t *testing.T) {
+	cfg := newTestConfig(t)
+	ctx := context.Background()
+	author, _ := createTestUser(t, cfg, roleUser)
+	moderator, moderatorToken := createTestUser(t, cfg, roleModerator)
+	chirp := createTestChirp(t, cfg, author.ID, "held once", visibilityPublic)
+	if err := cfg.dbQueries.FlagChirp(ctx, database.FlagChirpParams{ID: chirp.ID, FlagReason: "banned word"}); err != nil {
+		t.Fatal(err)
+	}
+
+	review := func() int {
+		r := httptest.NewRequest("POST", "/admin/chirps/"+chirp.ID.String()+"/review", strings.NewReader(`{"decision":"approve","note":"fine"}`))
+		r.SetPathValue("chirpID", chirp.ID.String())
+		r.Header.Set("Authorization", "Bearer "+moderatorToken)
+		w := httptest.NewRecorder()
+		cfg.handleReviewFlaggedChirp(w, r)
+		return w.Code
+	}
+	if code := review(); code != http.StatusNoContent {
+		t.Fatalf("got status %d, want %d", code, http.StatusNoContent)
+	}
+	if code := review(); code != http.StatusConflict {
+		t.Errorf("reviewing again: got status %d, want %d", code, http.StatusConflict)
+	}
+
+	found, err := cfg.dbQueries.GetChirpForModeration(ctx, chirp.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.FlaggedAt.Valid || found.FlagReason != "" {
+		t.Errorf("approved chirp is still flagged: %v %q", found.FlaggedAt, found.FlagReason)
+	}
+	entries, err := cfg.dbQueries.ListModerationLog(ctx, database.ListModerationLogParams{RowLimit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Action != moderationActionApproveChirp ||
+		entries[0].ActorID.UUID != moderator.ID || entries[0].Note != "held for banned word: fine" {
+		t.Errorf("unexpected moderation log: %+v", entries)
+	}
+}
+
+func TestHeldChirpVisibleOnlyToAuthor(t *testing.T) {
+	cfg := newTestConfig(t)
+	ctx := context.Background()
+	author, _ := createTestUser(t, cfg, roleUser)
+	reader, _ := createTestUser(t, cfg, roleUser)
+	chirp := createTestChirp(t, cfg, author.ID, "held for now", visibilityPublic)
+	if err := cfg.dbQueries.FlagChirp(ctx, database.FlagChirpParams{ID: chirp.ID, FlagReason: "banned word"}); err != nil {
+		t.Fatal(err)
+	}
+
+	visibleTo := func(viewer uuid.NullUUID) bool {
+		_, err := cfg.dbQueries.GetChirpById(ctx, database.GetChirpByIdParams{ID: chirp.ID, ViewerID: viewer})
+		if err != nil && err != sql.ErrNoRows {
+			t.Fatal(err)
+		}
+		return err == nil
+	}
+	authorID := uuid.NullUUID{UUID: author.ID, Valid: true}
+	readerID := uuid.NullUUID{UUID: reader.ID, Valid: true}
+
+	if !visibleTo(authorID) {
+		t.Error("author cannot see their held chirp")
+	}
+	if visibleTo(readerID) || visibleTo(uuid.NullUUID{}) {
+		t.Error("held chirp is visible to other users")
+	}
+	if err := cfg.dbQueries.ClearChirpFlag(ctx, chirp.ID); err != nil {
+		t.Fatal(err)
+	}
+	if !visibleTo(readerID) {
+		t.Error("approved chirp is not visible to other users")
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handleBookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	cfg.setBookmark(w, r, true)
+}
+
+func (cfg *apiConfig) handleUnbookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	cfg.setBookmark(w, r, false)
+}
+
+// setBookmark adds or removes one of the caller's bookmarks. Bookmarks are
+// private, so unlike likes there is no counter on the chirp to keep in step.
+func (cfg *apiConfig) setBookmark(w http.ResponseWriter, r *http.Request, bookmark bool) {
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userUuid, err := cfg.activeUser(r)
+	if err != nil {
+		respondWithActiveUserError(w, err)
+		return
+	}
+
+	bookmarkParams := database.CreateBookmarkParams{UserID: userUuid, ChirpID: parsedChirpID}
+	if bookmark {
+		viewer := uuid.NullUUID{UUID: userUuid, Valid: true}
+		_, err = cfg.dbQueries.GetChirpById(r.Context(), database.GetChirpByIdParams{ID: parsedChirpID, ViewerID: viewer})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				respondWithError(w, http.StatusNotFound, "Chirp not found")
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+			return
+		}
+		_, err = cfg.dbQueries.CreateBookmark(r.Context(), bookmarkParams)
+	} else {
+		// Removing needs no visibility check, so a bookmark can still be
+		// dropped after its chirp is deleted or hidden.
+		_, err = cfg.dbQueries.DeleteBookmark(r.Context(), database.DeleteBookmarkParams(bookmarkParams))
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetBookmarks pages through the caller's bookmarks, most recently
+// saved first.
+func (cfg *apiConfig) handleGetBookmarks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	query := r.URL.Query()
+
+	userUuid, err := cfg.authenticatedUser(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	listParams := database.ListBookmarkedChirpsParams{UserID: userUuid, RowLimit: int32(limit + 1)}
+	cursorParam := query.Get("cursor")
+	if cursorParam != "" {
+		cursor, err := pagination.DecodeCursor(cursorParam)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		listParams.AfterCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		listParams.AfterChirpID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.dbQueries.ListBookmarkedChirps(r.Context(), listParams)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	// The cursor follows when each chirp was bookmarked, not when it was
+	// posted, so it is built here instead of by newChirpPage.
+	var nextCursor string
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[limit-1]
+		nextCursor = pagination.Cursor{CreatedAt: last.BookmarkedAt, ID: last.Chirp.ID}.Encode()
+	}
+	chirps := make([]database.Chirp, len(rows))
+	for i, row := range rows {
+		chirps[i] = row.Chirp
+	}
+	page, err := cfg.newChirpPage(r.Context(), uuid.NullUUID{UUID: userUuid, Valid: true}, chirps, limit)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	page.NextCursor = nextCursor
+	respondWithJSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Chirpy/internal/entities"
+	"github.com/google/uuid"
+)
+
+// chirpEntities parses a chirp body and resolves its mentions, producing the
+// JSON stored in chirps.entities. It must run on the final, filtered body so
+// the offsets line up with what clients receive.
+func (cfg *apiConfig) chirpEntities(ctx context.Context, body string) (json.RawMessage, error) {
+	found := entities.Parse(body)
+	handles := found.MentionHandles()
+	if len(handles) > 0 {
+		users, err := cfg.dbQueries.GetUserIdsByUsernames(ctx, handles)
+		if err != nil {
+			return nil, err
+		}
+		userIDs := make(map[string]uuid.UUID, len(users))
+		for _, u := range users {
+			userIDs[u.Username] = u.ID
+		}
+		found.ResolveMentions(userIDs)
+	}
+	return json.Marshal(found)
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+// chirpListQuery is the validated form of the GET /api/chirps query string.
+type chirpListQuery struct {
+	params database.ListChirpsAscParams
+	desc   bool
+	limit  int
+}
+
+func parseChirpListQuery(query url.Values) (chirpListQuery, error) {
+	listQuery := chirpListQuery{}
+
+	authorIDs, err := parseAuthorIDs(query)
+	if err != nil {
+		return chirpListQuery{}, err
+	}
+	listQuery.params.AuthorIds = authorIDs
+
+	sortParam := strings.ToLower(query.Get("sort"))
+	if sortParam != "" && sortParam != "asc" && sortParam != "desc" {
+		return chirpListQuery{}, errors.New("Invalid sort parameter")
+	}
+	listQuery.desc = sortParam == "desc"
+
+	since, err := parseTimeParam(query.Get("since"))
+	if err != nil {
+		return chirpListQuery{}, errors.New("Invalid since parameter")
+	}
+	until, err := parseTimeParam(query.Get("until"))
+	if err != nil {
+		return chirpListQuery{}, errors.New("Invalid until parameter")
+	}
+	if since.Valid && until.Valid && !since.Time.Before(until.Time) {
+		return chirpListQuery{}, errors.New("since must be before until")
+	}
+	listQuery.params.Since = since
+	listQuery.params.Until = until
+
+	listQuery.limit, err = pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		return chirpListQuery{}, err
+	}
+	cursorParam := query.Get("cursor")
+	if cursorParam != "" {
+		cursor, err := pagination.DecodeCursor(cursorParam)
+		if err != nil {
+			return chirpListQuery{}, err
+		}
+		listQuery.params.AfterCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		listQuery.params.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+	// Ask for one extra row so we know whether another page follows.
+	listQuery.params.RowLimit = int32(listQuery.limit + 1)
+
+	return listQuery, nil
+}
+
+// profileAuthor reports the author when the query lists a single user's
+// whole timeline, which is where their pinned chirp belongs. Time-bounded
+// queries are searches rather than profile views and get no pin.
+func (listQuery chirpListQuery) profileAuthor() (uuid.UUID, bool) {
+	p := listQuery.params
+	if len(p.AuthorIds) != 1 || p.Since.Valid || p.Until.Valid {
+		return uuid.UUID{}, false
+	}
+	return p.AuthorIds[0], true
+}
+
+// newChirpPage trims the extra look-ahead row fetched by the list queries,
+// turns it into next_cursor and hydrates the remaining chirps.
+func (cfg *apiConfig) newChirpPage(ctx context.Context, viewer uuid.NullUUID, chirps []database.Chirp, limit int) (chirpPage, error) {
+	page := chirpPage{Chirps: make([]chirp, 0, len(chirps))}
+	if len(chirps) > limit {
+		chirps = chirps[:limit]
+		last := chirps[limit-1]
+		page.NextCursor = pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	for _, v := range chirps {
+		page.Chirps = append(page.Chirps, chirpFromDB(v))
+	}
+
+	refs := make([]*chirp, len(page.Chirps))
+	for i := range page.Chirps {
+		refs[i] = &page.Chirps[i]
+	}
+	return page, cfg.hydrateChirps(ctx, viewer, refs)
+}
+
+// hydrateChirps fills in the parts of a chirp response that live outside its
+// own row: the original chirp a rechirp or quote points at, its media
+// attachments and poll, and whether the viewer has liked or bookmarked each
+// chirp.
+func (cfg *apiConfig) hydrateChirps(ctx context.Context, viewer uuid.NullUUID, chirps []*chirp) error {
+	embedded, err := cfg.embedReferencedChirps(ctx, viewer, chirps)
+	if err != nil {
+		return err
+	}
+	all := make([]*chirp, 0, len(chirps)+len(embedded))
+	all = append(all, chirps...)
+	all = append(all, embedded...)
+	if err := cfg.attachChirpMedia(ctx, all); err != nil {
+		return err
+	}
+	if err := cfg.attachPolls(ctx, viewer, all); err != nil {
+		return err
+	}
+	if !viewer.Valid {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, len(all))
+	for i, c := range all {
+		chirpIDs[i] = c.ID
+	}
+	likedIDs, err := cfg.dbQueries.GetLikedChirpIds(ctx, database.GetLikedChirpIdsParams{
+		UserID:   viewer.UUID,
+		ChirpIds: chirpIDs,
+	})
+	if err != nil {
+		return err
+	}
+	liked := make(map[uuid.UUID]bool, len(likedIDs))
+	for _, id := range likedIDs {
+		liked[id] = true
+	}
+	bookmarkedIDs, err := cfg.dbQueries.GetBookmarkedChirpIds(ctx, database.GetBookmarkedChirpIdsParams{
+		UserID:   viewer.UUID,
+		ChirpIds: chirpIDs,
+	})
+	if err != nil {
+		return err
+	}
+	bookmarked := make(map[uuid.UUID]bool, len(bookmarkedIDs))
+	for _, id := range bookmarkedIDs {
+		bookmarked[id] = true
+	}
+	for _, c := range all {
+		c.LikedByMe = liked[c.ID]
+		c.BookmarkedByMe = bookmarked[c.ID]
+	}
+	return nil
+}
+
+// embedReferencedChirps attaches the originals of rechirps and quotes and
+// returns the newly embedded chirps.
+func (cfg *apiConfig) embedReferencedChirps(ctx context.Context, viewer uuid.NullUUID, chirps []*chirp) ([]*chirp, error) {
+	var referencedIDs []uuid.UUID
+	for _, c := range chirps {
+		if c.RechirpOfID != nil {
+			referencedIDs = append(referencedIDs, *c.RechirpOfID)
+		}
+		if c.QuoteOfID != nil {
+			referencedIDs = append(referencedIDs, *c.QuoteOfID)
+		}
+	}
+	if len(referencedIDs) == 0 {
+		return nil, nil
+	}
+
+	originals, err := cfg.dbQueries.GetChirpsByIds(ctx, database.GetChirpsByIdsParams{
+		Ids:      referencedIDs,
+		ViewerID: viewer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]database.Chirp, len(originals))
+	for _, original := range originals {
+		byID[original.ID] = original
+	}
+	var embedded []*chirp
+	for _, c := range chirps {
+		if c.RechirpOfID != nil {
+			if original, ok := byID[*c.RechirpOfID]; ok {
+				returnChirp := chirpFromDB(original)
+				c.RechirpOf = &returnChirp
+				embedded = append(embedded, c.RechirpOf)
+			}
+		}
+		if c.QuoteOfID != nil {
+			if original, ok := byID[*c.QuoteOfID]; ok {
+				returnChirp := chirpFromDB(original)
+				c.QuotedChirp = &returnChirp
+				embedded = append(embedded, c.QuotedChirp)
+			}
+		}
+	}
+	return embedded, nil
+}
+
+// parseAuthorIDs accepts author_id repeated and/or as a comma-separated list.
+func parseAuthorIDs(query url.Values) ([]uuid.UUID, error) {
+	var authorIDs []uuid.UUID
+	for _, value := range query["author_id"] {
+		for _, authorIDParam := range strings.Split(value, ",") {
+			parsedAuthorID, err := uuid.Parse(strings.TrimSpace(authorIDParam))
+			if err != nil {
+				return nil, errors.New("Invalid author ID format")
+			}
+			authorIDs = append(authorIDs, parsedAuthorID)
+		}
+	}
+	return authorIDs, nil
+}
+
+// parseTimeParam reads an optional RFC 3339 timestamp. Chirp timestamps are
+// stored without a zone, so bounds are compared in UTC.
+func parseTimeParam(value string) (sql.NullTime, error) {
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+	return sql.NullTime{Time: parsed.UTC(), Valid: true}, nil
+}
+
+func (cfg *apiConfig) listChirps(ctx context.Context, listQuery chirpListQuery) ([]database.Chirp, error) {
+	if listQuery.desc {
+		return cfg.dbQueries.ListChirpsDesc(ctx, database.ListChirpsDescParams(listQuery.params))
+	}
+	return cfg.dbQueries.ListChirpsAsc(ctx, listQuery.params)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+func TestParseChirpListQuery_Defaults(t *testing.T) {
+	listQuery, err := parseChirpListQuery(url.Values{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if listQuery.desc {
+		t.Error("Expected ascending sort by default")
+	}
+	if listQuery.limit != pagination.DefaultLimit {
+		t.Errorf("Expected limit %d, got %d", pagination.DefaultLimit, listQuery.limit)
+	}
+	if listQuery.params.RowLimit != int32(pagination.DefaultLimit+1) {
+		t.Errorf("Expected row limit %d, got %d", pagination.DefaultLimit+1, listQuery.params.RowLimit)
+	}
+	if len(listQuery.params.AuthorIds) != 0 || listQuery.params.Since.Valid || listQuery.params.Until.Valid {
+		t.Error("Expected no filters by default")
+	}
+}
+
+func TestParseChirpListQuery_Filters(t *testing.T) {
+	first, second, third := uuid.New(), uuid.New(), uuid.New()
+	cursor := pagination.Cursor{CreatedAt: time.Now().UTC(), ID: uuid.New()}
+	query := url.Values{
+		"author_id": {first.String() + "," + second.String(), third.String()},
+		"sort":      {"DESC"},
+		"since":     {"2025-01-01T00:00:00Z"},
+		"until":     {"2025-02-01T00:00:00+01:00"},
+		"limit":     {"10"},
+		"cursor":    {cursor.Encode()},
+	}
+
+	listQuery, err := parseChirpListQuery(query)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !listQuery.desc {
+		t.Error("Expected descending sort")
+	}
+	if len(listQuery.params.AuthorIds) != 3 || listQuery.params.AuthorIds[2] != third {
+		t.Errorf("Expected three author IDs, got %v", listQuery.params.AuthorIds)
+	}
+	if want := time.Date(2025, 1, 31, 23, 0, 0, 0, time.UTC); !listQuery.params.Until.Time.Equal(want) {
+		t.Errorf("Expected until %v, got %v", want, listQuery.params.Until.Time)
+	}
+	if listQuery.params.AfterID.UUID != cursor.ID {
+		t.Errorf("Expected cursor id %v, got %v", cursor.ID, listQuery.params.AfterID.UUID)
+	}
+	if listQuery.limit != 10 {
+		t.Errorf("Expected limit 10, got %d", listQuery.limit)
+	}
+}
+
+func TestParseChirpListQuery_Invalid(t *testing.T) {
+	inputs := []url.Values{
+		{"author_id": {"not-a-uuid"}},
+		{"sort": {"sideways"}},
+		{"since": {"yesterday"}},
+		{"since": {"2025-02-01T00:00:00Z"}, "until": {"2025-01-01T00:00:00Z"}},
+		{"limit": {"1000"}},
+		{"cursor": {"garbage"}},
+	}
+	for _, input := range inputs {
+		if _, err := parseChirpListQuery(input); err == nil {
+			t.Errorf("Expected error for query %v", input)
+		}
+	}
+}
+
+func TestListChirps_SkipsRechirpsOfUnavailableOriginals(t *testing.T) {
+	cfg := newTestConfig(t)
+	ctx := context.Background()
+	author, _ := createTestUser(t, cfg, roleUser)
+	rechirper, _ := createTestUser(t, cfg, roleUser)
+	viewer, _ := createTestUser(t, cfg, roleUser)
+
+	live := createTestChirp(t, cfg, author.ID, "still here", visibilityPublic)
+	hidden := createTestChirp(t, cfg, author.ID, "hidden later", visibilityPublic)
+	followersOnly := createTestChirp(t, cfg, author.ID, "for followers", visibilityFollowers)
+	want := map[uuid.UUID]bool{}
+	for _, original := range []database.Chirp{live, hidden, followersOnly} {
+		rechirp, err := cfg.dbQueries.CreateRechirp(ctx, database.CreateRechirpParams{
+			UserID:      rechirper.ID,
+			RechirpOfID: uuid.NullUUID{UUID: original.ID, Valid: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[rechirp.ID] = original.ID == live.ID
+	}
+	if err := cfg.dbQueries.HideChirp(ctx, hidden.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	chirps, err := cfg.dbQueries.ListChirpsDesc(ctx, database.ListChirpsDescParams{
+		ViewerID:  uuid.NullUUID{UUID: viewer.ID, Valid: true},
+		AuthorIds: []uuid.UUID{rechirper.ID},
+		RowLimit:  10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[uuid.UUID]bool{}
+	for _, c := range chirps {
+		got[c.ID] = true
+	}
+	for id, listed := range want {
+		if got[id] != listed {
+			t.Errorf("rechirp %s: listed %v, want %v", id, got[id], listed)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const cliUsage = "usage: chirpy grant-role <email> <user|moderator|admin>"
+
+// runCommand runs a one-off command given on the command line instead of
+// starting the server. grant-role is how the first admin is made, since the
+// role endpoint needs an admin or the ADMIN_KEY.
+func (cfg *apiConfig) runCommand(ctx context.Context, args []string) error {
+	switch args[0] {
+	case "grant-role":
+		if len(args) != 3 {
+			return errors.New(cliUsage)
+		}
+		return cfg.grantRole(ctx, args[1], args[2])
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], cliUsage)
+	}
+}
+
+func (cfg *apiConfig) grantRole(ctx context.Context, email, role string) error {
+	return cfg.withTx(ctx, func(q *database.Queries) error {
+		found, err := q.GetUserByEmail(ctx, email)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("no user with email %s", email)
+		}
+		if err != nil {
+			return err
+		}
+		// Grants from the command line have no acting user, like the
+		// ADMIN_KEY.
+		if _, err := setUserRole(ctx, q, uuid.NullUUID{}, found.ID, role); err != nil {
+			return err
+		}
+		log.Printf("%s is now %s", email, role)
+		return nil
+	})
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Drafts are only checked against the chirp rules when they are published,
+// so this just keeps a stored draft from growing without bound.
+const maxChirpDraftLength = 1000
+
+var errDraftTooLong = errors.New("Draft is too long")
+
+type chirpDraft struct {
+	ID            uuid.UUID  `json:"id"`
+	CreatedAt     string     `json:"created_at"`
+	UpdatedAt     string     `json:"updated_at"`
+	Body          string     `json:"body"`
+	ParentChirpID *uuid.UUID `json:"parent_chirp_id"`
+}
+
+type chirpDraftParameters struct {
+	Body          string     `json:"body"`
+	ParentChirpID *uuid.UUID `json:"parent_chirp_id"`
+}
+
+func chirpDraftFromDB(dbDraft database.ChirpDraft) chirpDraft {
+	returnDraft := chirpDraft{
+		ID:        dbDraft.ID,
+		CreatedAt: dbDraft.CreatedAt.String(),
+		UpdatedAt: dbDraft.UpdatedAt.String(),
+		Body:      dbDraft.Body,
+	}
+	if dbDraft.ParentChirpID.Valid {
+		returnDraft.ParentChirpID = &dbDraft.ParentChirpID.UUID
+	}
+	return returnDraft
+}
+
+// decodeChirpDraft reads a draft body from the request.
+func decodeChirpDraft(r *http.Request) (chirpDraftParameters, error) {
+	decoder := json.NewDecoder(r.Body)
+	params := chirpDraftParameters{}
+	if err := decoder.Decode(&params); err != nil {
+		return chirpDraftParameters{}, err
+	}
+	if len(params.Body) > maxChirpDraftLength {
+		return chirpDraftParameters{}, errDraftTooLong
+	}
+	return params, nil
+}
+
+func nullUUID(id *uuid.UUID) uuid.NullUUID {
+	if id == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *id, Valid: true}
+}
+
+func (cfg *apiConfig) handleCreateDraft(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userUuid, err := cfg.activeUser(r)
+	if err != nil {
+		respondWithActiveUserError(w, err)
+		return
+	}
+	params, err := decodeChirpDraft(r)
+	if err != nil {
+		if err == errDraftTooLong {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Something went wrong")
+		return
+	}
+
+	draft, err := cfg.dbQueries.CreateChirpDraft(r.Context(), database.CreateChirpDraftParams{
+		UserID:        userUuid,
+		Body:          params.Body,
+		ParentChirpID: nullUUID(params.ParentChirpID),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, chirpDraftFromDB(draft))
+}
+
+// handleGetDrafts lists the caller's drafts, most recently edited first.
+func (cfg *apiConfig) handleGetDrafts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userUuid, err := cfg.authenticatedUser(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	dbDrafts, err := cfg.dbQueries.ListChirpDrafts(r.Context(), userUuid)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	drafts := make([]chirpDraft, 0, len(dbDrafts))
+	for _, d := range dbDrafts {
+		drafts = append(drafts, chirpDraftFromDB(d))
+	}
+	respondWithJSON(w, http.StatusOK, drafts)
+}
+
+func (cfg *apiConfig) handleGetDraft(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	parsedDraftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userUuid, err := cfg.authenticatedUser(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	draft, err := cfg.dbQueries.GetChirpDraft(r.Context(), database.GetChirpDraftParams{ID: parsedDraftID, UserID: userUuid})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Draft not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, chirpDraftFromDB(draft))
+}
+
+func (cfg *apiConfig) handleUpdateDraft(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	parsedDraftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userUuid, err := cfg.activeUser(r)
+	if err != nil {
+		respondWithActiveUserError(w, err)
+		return
+	}
+	params, err := decodeChirpDraft(r)
+	if err != nil {
+		if err == errDraftTooLong {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Something went wrong")
+		return
+	}
+
+	draft, err := cfg.dbQueries.UpdateChirpDraft(r.Context(), database.UpdateChirpDraftParams{
+		ID:            parsedDraftID,
+		UserID:        userUuid,
+		Body:          params.Body,
+		ParentChirpID: nullUUID(params.ParentChirpID),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Draft not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, chirpDraftFromDB(draft))
+}
+
+func (cfg *apiConfig) handleDeleteDraft(w http.ResponseWriter, r *http.Request) {
+	parsedDraftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userUuid, err := cfg.activeUser(r)
+	if err != nil {
+		respondWithActiveUserError(w, err)
+		return
+	}
+
+	deleted, err := cfg.dbQueries.DeleteChirpDraft(r.Context(), database.DeleteChirpDraftParams{ID: parsedDraftID, UserID: userUuid})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Draft not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePublishDraft turns a draft into a chirp through the same checks as
+// handleChirps. The draft is removed in the same transaction, so publishing
+// twice cannot post it twice. An optional publish_at schedules it instead.
+func (cfg *apiConfig) handlePublishDraft(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		PublishAt *time.Time `json:"publish_at"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	parsedDraftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userUuid, err := cfg.activeUser(r)
+	if err != nil {
+		respondWithActiveUserError(w, err)
+		return
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil && err != io.EOF {
+		respondWithError(w, http.StatusBadRequest, "Something went wrong")
+		return
+	}
+
+	draftParams := database.GetChirpDraftParams{ID: parsedDraftID, UserID: userUuid}
+	draft, err := cfg.dbQueries.GetChirpDraft(r.Context(), draftParams)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Draft not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	input := newChirpInput{Body: draft.Body, PublishAt: params.PublishAt}
+	if draft.ParentChirpID.Valid {
+		input.ParentChirpID = &draft.ParentChirpID.UUID
+	}
+	prepared, err := cfg.prepareNewChirp(r.Context(), userUuid, input)
+	if err != nil {
+		respondWithNewChirpError(w, err)
+		return
+	}
+
+	var published database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		deleted, err := q.DeleteChirpDraft(r.Context(), database.DeleteChirpDraftParams(draftParams))
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return sql.ErrNoRows
+		}
+		published, err = insertChirp(r.Context(), q, prepared)
+		return err
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Draft not found")
+			return
+		}
+		respondWithNewChirpError(w, err)
+		return
+	}
+	returnChirp := chirpFromDB(published)
+	err = cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userUuid, Valid: true}, []*chirp{&returnChirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, returnChirp)
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/Chirpy/internal/entities"
+	"github.com/Chirpy/internal/moderation"
+	"github.com/google/uuid"
+)
+
+const defaultChirpEditWindow = 15 * time.Minute
+
+var (
+	errEditForbidden     = errors.New("Forbidden")
+	errEditWindowExpired = errors.New("Edit window has passed")
+	errEditNotEditable   = errors.New("Chirp cannot be edited")
+)
+
+type chirpRevision struct {
+	Body       string            `json:"body"`
+	Entities   entities.Entities `json:"entities"`
+	CreatedAt  string            `json:"created_at"`
+	ReplacedAt string            `json:"replaced_at"`
+}
+
+func (cfg *apiConfig) handleChirpUpdate(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userUuid, err := cfg.activeUser(r)
+	if err != nil {
+		respondWithActiveUserError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Something went wrong")
+		return
+	}
+	limit, err := cfg.chirpLengthLimit(r.Context(), userUuid)
+	if err != nil {
+		respondWithNewChirpError(w, err)
+		return
+	}
+	if err := checkChirpLength(params.Body, limit); err != nil {
+		respondWithNewChirpError(w, err)
+		return
+	}
+	// A chirp's parent never changes, so it can be read ahead of the edit.
+	parentID, err := cfg.dbQueries.GetChirpParentId(r.Context(), parsedChirpID)
+	if err == sql.ErrNoRows {
+		respondWithError(w, http.StatusNotFound, "Chirp not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	moderated, err := cfg.moderators.Run(r.Context(), moderation.Chirp{
+		ID:       parsedChirpID,
+		AuthorID: userUuid,
+		ParentID: parentID,
+		Body:     params.Body,
+	})
+	if err != nil {
+		respondWithNewChirpError(w, err)
+		return
+	}
+	params.Body = moderated.Chirp.Body
+	chirpEntities, err := cfg.chirpEntities(r.Context(), params.Body)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	// The row is locked for the whole edit so concurrent edits each archive
+	// the version they actually replaced.
+	var updatedChirp database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		current, err := q.GetChirpByIdForUpdate(r.Context(), parsedChirpID)
+		if err != nil {
+			return err
+		}
+		if current.UserID != userUuid {
+			return errEditForbidden
+		}
+		if current.RechirpOfID.Valid {
+			return errEditNotEditable
+		}
+		if time.Since(current.CreatedAt) > cfg.chirpEditWindow {
+			return errEditWindowExpired
+		}
+
+		// The revision dates from when its text went up: the last edit, or
+		// the chirp itself. updated_at also moves for deletes, reschedules
+		// and the like, so it cannot stand in.
+		publishedAt := current.CreatedAt
+		if current.EditedAt.Valid {
+			publishedAt = current.EditedAt.Time
+		}
+		err = q.CreateChirpRevision(r.Context(), database.CreateChirpRevisionParams{
+			ChirpID:   current.ID,
+			Body:      current.Body,
+			Entities:  current.Entities,
+			CreatedAt: publishedAt,
+		})
+		if err != nil {
+			return err
+		}
+		updatedChirp, err = q.UpdateChirpBody(r.Context(), database.UpdateChirpBodyParams{
+			ID:       current.ID,
+			Body:     params.Body,
+			Entities: chirpEntities,
+		})
+		if err != nil {
+			return err
+		}
+		if err := q.DeleteChirpHashtags(r.Context(), current.ID); err != nil {
+			return err
+		}
+		if err := flagHeldChirp(r.Context(), q, current.ID, moderated); err != nil {
+			return err
+		}
+		return saveChirpHashtags(r.Context(), q, updatedChirp)
+	})
+	switch {
+	case err == sql.ErrNoRows:
+		respondWithError(w, http.StatusNotFound, "Chirp not found")
+		return
+	case err == errEditForbidden || err == errEditWindowExpired:
+		respondWithError(w, http.StatusForbidden, err.Error())
+		return
+	case err == errEditNotEditable:
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	returnChirp := chirpFromDB(updatedChirp)
+	err = cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userUuid, Valid: true}, []*chirp{&returnChirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, returnChirp)
+}
+
+// handleGetChirpHistory lists the earlier versions of a chirp, newest first.
+func (cfg *apiConfig) handleGetChirpHistory(w http.ResponseWriter, r *http.Request) {
+	type historyResponse struct {
+		Revisions []chirpRevision `json:"revisions"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	viewer, err := cfg.optionalUser(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	_, err = cfg.dbQueries.GetChirpById(r.Context(), database.GetChirpByIdParams{ID: parsedChirpID, ViewerID: viewer})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	revisions, err := cfg.dbQueries.ListChirpRevisions(r.Context(), parsedChirpID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	response := historyResponse{Revisions: make([]chirpRevision, 0, len(revisions))}
+	for _, v := range revisions {
+		revision := chirpRevision{
+			Body:       v.Body,
+			CreatedAt:  v.CreatedAt.String(),
+			ReplacedAt: v.ReplacedAt.String(),
+		}
+		_ = json.Unmarshal(v.Entities, &revision.Entities)
+		response.Revisions = append(response.Revisions, revision)
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
@@ -6,7 +6,8 @@ require (
 	github.com/google/uuid v1.6.0
 	github.com/joho/godotenv v1.5.1
 	github.com/lib/pq v1.10.9
+	github.com/rivo/uniseg v0.4.7
 	golang.org/x/crypto v0.41.0
 )
 
-require github.com/golang-jwt/jwt/v5 v5.3.0 // indirect
+require github.com/golang-jwt/jwt/v5 v5.3.0
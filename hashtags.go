@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/Chirpy/internal/entities"
+	"github.com/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultTrendingWindow = 24 * time.Hour
+	maxTrendingWindow     = 7 * 24 * time.Hour
+)
+
+type trendingHashtag struct {
+	Tag        string `json:"tag"`
+	ChirpCount int64  `json:"chirp_count"`
+}
+
+// saveChirpHashtags records the tags used in a newly written chirp. It runs
+// inside the transaction that creates the chirp.
+func saveChirpHashtags(ctx context.Context, q *database.Queries, dbChirp database.Chirp) error {
+	tags := entities.Hashtags(dbChirp.Body)
+	if len(tags) == 0 {
+		return nil
+	}
+	if err := q.CreateHashtags(ctx, tags); err != nil {
+		return err
+	}
+	return q.CreateChirpHashtags(ctx, database.CreateChirpHashtagsParams{
+		ChirpID:   dbChirp.ID,
+		CreatedAt: dbChirp.CreatedAt,
+		Tags:      tags,
+	})
+}
+
+func (cfg *apiConfig) handleGetHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	query := r.URL.Query()
+
+	tag, ok := entities.NormalizeHashtag(r.PathValue("tag"))
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "Invalid hashtag")
+		return
+	}
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	viewer, err := cfg.optionalUser(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	listParams := database.ListChirpsByHashtagParams{Tag: tag, ViewerID: viewer, RowLimit: int32(limit + 1)}
+	cursorParam := query.Get("cursor")
+	if cursorParam != "" {
+		cursor, err := pagination.DecodeCursor(cursorParam)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		listParams.AfterCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		listParams.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	chirps, err := cfg.dbQueries.ListChirpsByHashtag(r.Context(), listParams)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	page, err := cfg.newChirpPage(r.Context(), viewer, chirps, limit)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// handleTrendingHashtags ranks tags by how many chirps used them within the
+// trailing `window` (a Go duration such as "6h", default 24h).
+func (cfg *apiConfig) handleTrendingHashtags(w http.ResponseWriter, r *http.Request) {
+	type trendingResponse struct {
+		Hashtags []trendingHashtag `json:"hashtags"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	query := r.URL.Query()
+
+	window := defaultTrendingWindow
+	windowParam := query.Get("window")
+	if windowParam != "" {
+		parsed, err := time.ParseDuration(windowParam)
+		if err != nil || parsed < time.Minute || parsed > maxTrendingWindow {
+			respondWithError(w, http.StatusBadRequest, "window must be a duration between 1m and 168h")
+			return
+		}
+		window = parsed
+	}
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trending, err := cfg.dbQueries.ListTrendingHashtags(r.Context(), database.ListTrendingHashtagsParams{
+		Since:    time.Now().UTC().Add(-window),
+		RowLimit: int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	response := trendingResponse{Hashtags: make([]trendingHashtag, 0, len(trending))}
+	for _, v := range trending {
+		response.Hashtags = append(response.Hashtags, trendingHashtag{Tag: v.Tag, ChirpCount: v.ChirpCount})
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
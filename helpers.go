@@ -2,7 +2,10 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+
+	"github.com/lib/pq"
 )
 
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
@@ -31,3 +34,16 @@ func respondWithError(w http.ResponseWriter, code int, responseString string) {
 	}
 	return
 }
+
+// isUniqueViolation reports whether err is Postgres rejecting a duplicate key.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// isUniqueViolationOn is isUniqueViolation for one named index or constraint,
+// for tables with more than one unique key.
+func isUniqueViolationOn(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
@@ -10,12 +10,22 @@ import (
 	"github.com/google/uuid"
 )
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer:    "chirpy",
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
-		Subject:   userID.String(),
+// Claims is what a Chirpy JWT carries: the user as the subject, plus their
+// role.
+type Claims struct {
+	Role string `json:"role,omitempty"`
+	jwt.RegisteredClaims
+}
+
+func MakeJWT(userID uuid.UUID, role string, tokenSecret string, expiresIn time.Duration) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "chirpy",
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+			Subject:   userID.String(),
+		},
 	})
 	tokenString, err := token.SignedString([]byte(tokenSecret))
 	if err != nil {
@@ -25,27 +35,34 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
 }
 
 func ValidateJWT(tokenString, serverJWTSecret string) (uuid.UUID, error) {
-	var userClaims jwt.RegisteredClaims
+	userID, _, err := ValidateJWTWithRole(tokenString, serverJWTSecret)
+	return userID, err
+}
+
+// ValidateJWTWithRole also returns the role the token was issued with, which
+// is empty for tokens issued before roles existed.
+func ValidateJWTWithRole(tokenString, serverJWTSecret string) (uuid.UUID, string, error) {
+	var userClaims Claims
 
 	token, err := jwt.ParseWithClaims(tokenString, &userClaims, func(token *jwt.Token) (interface{}, error) {
 		return []byte(serverJWTSecret), nil
 	})
 	if err != nil {
-		return uuid.UUID{}, err
+		return uuid.UUID{}, "", err
 	}
 	if !token.Valid {
-		return uuid.UUID{}, err
+		return uuid.UUID{}, "", err
 	}
 	subject, err := token.Claims.GetSubject()
 	if err != nil {
-		return uuid.UUID{}, err
+		return uuid.UUID{}, "", err
 	}
 	userID, err := uuid.Parse(subject)
 	if err != nil {
-		return uuid.UUID{}, err
+		return uuid.UUID{}, "", err
 	}
 
-	return userID, nil
+	return userID, userClaims.Role, nil
 }
 
 func GetBearerToken(headers http.Header) (string, error) {
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
@@ -12,7 +13,7 @@ func TestMakeJWT(t *testing.T) {
 	secret := "test-secret"
 	expiresIn := time.Hour
 
-	token, err := MakeJWT(userID, secret, expiresIn)
+	token, err := MakeJWT(userID, "user", secret, expiresIn)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -37,7 +38,7 @@ func TestValidateJWT_ValidToken(t *testing.T) {
 	secret := "test-secret"
 	expiresIn := time.Hour
 
-	token, err := MakeJWT(userID, secret, expiresIn)
+	token, err := MakeJWT(userID, "user", secret, expiresIn)
 	if err != nil {
 		t.Fatalf("Failed to create token: %v", err)
 	}
@@ -57,7 +58,7 @@ func TestValidateJWT_ExpiredToken(t *testing.T) {
 	secret := "test-secret"
 	expiresIn := -time.Hour // Expired 1 hour ago
 
-	token, err := MakeJWT(userID, secret, expiresIn)
+	token, err := MakeJWT(userID, "user", secret, expiresIn)
 	if err != nil {
 		t.Fatalf("Failed to create token: %v", err)
 	}
@@ -74,7 +75,7 @@ func TestValidateJWT_WrongSecret(t *testing.T) {
 	wrongSecret := "wrong-secret"
 	expiresIn := time.Hour
 
-	token, err := MakeJWT(userID, secret, expiresIn)
+	token, err := MakeJWT(userID, "user", secret, expiresIn)
 	if err != nil {
 		t.Fatalf("Failed to create token: %v", err)
 	}
@@ -108,7 +109,7 @@ func TestMakeJWT_EmptySecret(t *testing.T) {
 	userID := uuid.New()
 	expiresIn := time.Hour
 
-	token, err := MakeJWT(userID, "", expiresIn)
+	token, err := MakeJWT(userID, "user", "", expiresIn)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -129,7 +130,7 @@ func TestJWT_ShortExpiration(t *testing.T) {
 	secret := "test-secret"
 	expiresIn := time.Millisecond * 10
 
-	token, err := MakeJWT(userID, secret, expiresIn)
+	token, err := MakeJWT(userID, "user", secret, expiresIn)
 	if err != nil {
 		t.Fatalf("Failed to create token: %v", err)
 	}
@@ -142,3 +143,39 @@ func TestJWT_ShortExpiration(t *testing.T) {
 		t.Fatal("Expected error for expired token, got none")
 	}
 }
+
+func TestValidateJWTWithRole(t *testing.T) {
+	userID := uuid.New()
+	secret := "test-secret"
+
+	token, err := MakeJWT(userID, "moderator", secret, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	parsedUserID, role, err := ValidateJWTWithRole(token, secret)
+	if err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+	if parsedUserID != userID || role != "moderator" {
+		t.Errorf("Expected %v as moderator, got %v as %q", userID, parsedUserID, role)
+	}
+}
+
+func TestValidateJWTWithRole_NoRole(t *testing.T) {
+	userID := uuid.New()
+	secret := "test-secret"
+
+	// Tokens issued before roles existed only have the registered claims.
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    "chirpy",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		Subject:   userID.String(),
+	}).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	parsedUserID, role, err := ValidateJWTWithRole(token, secret)
+	if err != nil || parsedUserID != userID || role != "" {
+		t.Errorf("Expected %v with no role, got %v, %q, %v", userID, parsedUserID, role, err)
+	}
+}
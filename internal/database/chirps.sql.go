@@ -7,23 +7,107 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const addChirpViews = `-- name: AddChirpViews :exec
+update chirps set view_count = chirps.view_count + views.count
+from unnest($1::uuid[], $2::bigint[]) as views(id, count)
+where chirps.id = views.id
+`
+
+type AddChirpViewsParams struct {
+	Ids    []uuid.UUID
+	Counts []int64
+}
+
+// Applies a batch of view counts; ids and counts are parallel arrays.
+func (q *Queries) AddChirpViews(ctx context.Context, arg AddChirpViewsParams) error {
+	_, err := q.db.ExecContext(ctx, addChirpViews, pq.Array(arg.Ids), pq.Array(arg.Counts))
+	return err
+}
+
+const clearChirpFlag = `-- name: ClearChirpFlag :exec
+update chirps set flagged_at = null, flag_reason = '' where id = $1
+`
+
+// Approves a held chirp, taking it off the review list.
+func (q *Queries) ClearChirpFlag(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, clearChirpFlag, id)
+	return err
+}
+
 const createChirp = `-- name: CreateChirp :one
-insert into chirps (created_at, updated_at, body, user_id)
-values (now(),now(), $1, $2)
-returning id, created_at, updated_at, body, user_id
+insert into chirps (created_at, updated_at, body, user_id, parent_chirp_id, root_chirp_id, quote_of_id, entities, publish_at, visibility, content_warning, sensitive)
+values (coalesce($7, now()),now(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+returning id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, root_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quote_of_id, rechirp_count, quote_count, like_count, entities, edited_at, deleted_at, publish_at, visibility, content_warning, sensitive, view_count, flagged_at, flag_reason, hidden_at
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body           string
+	UserID         uuid.UUID
+	ParentChirpID  uuid.NullUUID
+	RootChirpID    uuid.NullUUID
+	QuoteOfID      uuid.NullUUID
+	Entities       json.RawMessage
+	PublishAt      sql.NullTime
+	Visibility     string
+	ContentWarning string
+	Sensitive      bool
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID, arg.ParentChirpID, arg.RootChirpID, arg.QuoteOfID, arg.Entities, arg.PublishAt, arg.Visibility, arg.ContentWarning, arg.Sensitive)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ParentChirpID,
+		&i.RootChirpID,
+		&i.ReplyCount,
+		&i.TombstonedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.LikeCount,
+		&i.Entities,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ViewCount,
+		&i.FlaggedAt,
+		&i.FlagReason,
+		&i.HiddenAt,
+	)
+	return i, err
+}
+
+const createRechirp = `-- name: CreateRechirp :one
+insert into chirps (created_at, updated_at, body, user_id, rechirp_of_id)
+values (now(), now(), '', $1, $2)
+returning id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, root_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quote_of_id, rechirp_count, quote_count, like_count, entities, edited_at, deleted_at, publish_at, visibility, content_warning, sensitive, view_count, flagged_at, flag_reason, hidden_at
+`
+
+type CreateRechirpParams struct {
+	UserID      uuid.UUID
+	RechirpOfID uuid.NullUUID
+}
+
+func (q *Queries) CreateRechirp(ctx context.Context, arg CreateRechirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, createRechirp, arg.UserID, arg.RechirpOfID)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -31,12 +115,61 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.ParentChirpID,
+		&i.RootChirpID,
+		&i.ReplyCount,
+		&i.TombstonedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.LikeCount,
+		&i.Entities,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ViewCount,
+		&i.FlaggedAt,
+		&i.FlagReason,
+		&i.HiddenAt,
 	)
 	return i, err
 }
 
-const deleteChirp = `-- name: DeleteChirp :exec
-delete from chirps where id = $1 and user_id = $2
+const decrementQuoteCount = `-- name: DecrementQuoteCount :exec
+update chirps set quote_count = greatest(quote_count - 1, 0) where id = $1
+`
+
+func (q *Queries) DecrementQuoteCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, decrementQuoteCount, id)
+	return err
+}
+
+const decrementRechirpCount = `-- name: DecrementRechirpCount :exec
+update chirps set rechirp_count = greatest(rechirp_count - 1, 0) where id = $1
+`
+
+func (q *Queries) DecrementRechirpCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, decrementRechirpCount, id)
+	return err
+}
+
+const decrementReplyCount = `-- name: DecrementReplyCount :exec
+update chirps set reply_count = greatest(reply_count - 1, 0) where id = $1
+`
+
+func (q *Queries) DecrementReplyCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, decrementReplyCount, id)
+	return err
+}
+
+const deleteChirp = `-- name: DeleteChirp :execrows
+update chirps set deleted_at = now(), updated_at = now()
+where id = $1 and user_id = $2 and deleted_at is null
 `
 
 type DeleteChirpParams struct {
@@ -44,17 +177,175 @@ type DeleteChirpParams struct {
 	UserID uuid.UUID
 }
 
-func (q *Queries) DeleteChirp(ctx context.Context, arg DeleteChirpParams) error {
-	_, err := q.db.ExecContext(ctx, deleteChirp, arg.ID, arg.UserID)
+// Deletes are soft until PurgeExpiredChirps runs, so owners can restore them.
+func (q *Queries) DeleteChirp(ctx context.Context, arg DeleteChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteChirp, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deletePendingChirp = `-- name: DeletePendingChirp :execrows
+delete from chirps
+where id = $1 and user_id = $2 and deleted_at is null and publish_at > now()
+`
+
+type DeletePendingChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) DeletePendingChirp(ctx context.Context, arg DeletePendingChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePendingChirp, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteRechirp = `-- name: DeleteRechirp :execrows
+delete from chirps where user_id = $1 and rechirp_of_id = $2 and deleted_at is null
+`
+
+type DeleteRechirpParams struct {
+	UserID      uuid.UUID
+	RechirpOfID uuid.NullUUID
+}
+
+// A rechirp already deleted through DELETE /api/chirps/{chirpID} has had its
+// count adjusted, so only live ones match.
+func (q *Queries) DeleteRechirp(ctx context.Context, arg DeleteRechirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteRechirp, arg.UserID, arg.RechirpOfID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const flagChirp = `-- name: FlagChirp :exec
+update chirps
+set flag_reason = coalesce((
+        select string_agg(reason, '; ' order by first_seen)
+        from (
+            select reason, min(ord) as first_seen
+            from unnest(string_to_array(
+                case when flagged_at is null or flag_reason = '' then $2::text
+                     else flag_reason || '; ' || $2::text end,
+                '; ')) with ordinality as held(reason, ord)
+            group by reason
+        ) reasons
+    ), ''),
+    flagged_at = coalesce(flagged_at, now())
+where id = $1
+`
+
+type FlagChirpParams struct {
+	ID         uuid.UUID
+	FlagReason string
+}
+
+// Keeps the first flag time so re-flagging does not move a chirp in the
+// review list, and adds any reasons the chirp was not already held for.
+func (q *Queries) FlagChirp(ctx context.Context, arg FlagChirpParams) error {
+	_, err := q.db.ExecContext(ctx, flagChirp, arg.ID, arg.FlagReason)
 	return err
 }
 
 const getChirpById = `-- name: GetChirpById :one
-select id, created_at, updated_at, body, user_id from chirps where id = $1
+select id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, root_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quote_of_id, rechirp_count, quote_count, like_count, entities, edited_at, deleted_at, publish_at, visibility, content_warning, sensitive, view_count, flagged_at, flag_reason, hidden_at from chirps
+where id = $1
+  and deleted_at is null
+  and (publish_at is null or publish_at <= now())
+  and chirp_visible_to(user_id, visibility, entities, flagged_at, $2::uuid)
+`
+
+type GetChirpByIdParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+// Chirps the viewer may not see come back as sql.ErrNoRows, the same as
+// chirps that do not exist.
+func (q *Queries) GetChirpById(ctx context.Context, arg GetChirpByIdParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpById, arg.ID, arg.ViewerID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ParentChirpID,
+		&i.RootChirpID,
+		&i.ReplyCount,
+		&i.TombstonedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.LikeCount,
+		&i.Entities,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ViewCount,
+		&i.FlaggedAt,
+		&i.FlagReason,
+		&i.HiddenAt,
+	)
+	return i, err
+}
+
+const getChirpByIdForUpdate = `-- name: GetChirpByIdForUpdate :one
+select id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, root_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quote_of_id, rechirp_count, quote_count, like_count, entities, edited_at, deleted_at, publish_at, visibility, content_warning, sensitive, view_count, flagged_at, flag_reason, hidden_at from chirps where id = $1 and deleted_at is null for update
+`
+
+func (q *Queries) GetChirpByIdForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpByIdForUpdate, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ParentChirpID,
+		&i.RootChirpID,
+		&i.ReplyCount,
+		&i.TombstonedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.LikeCount,
+		&i.Entities,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ViewCount,
+		&i.FlaggedAt,
+		&i.FlagReason,
+		&i.HiddenAt,
+	)
+	return i, err
+}
+
+const getChirpForModeration = `-- name: GetChirpForModeration :one
+select id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, root_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quote_of_id, rechirp_count, quote_count, like_count, entities, edited_at, deleted_at, publish_at, visibility, content_warning, sensitive, view_count, flagged_at, flag_reason, hidden_at from chirps where id = $1 for update
 `
 
-func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, getChirpById, id)
+// A chirp in any state, locked while a moderator acts on it.
+func (q *Queries) GetChirpForModeration(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpForModeration, id)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -62,16 +353,57 @@ func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.ParentChirpID,
+		&i.RootChirpID,
+		&i.ReplyCount,
+		&i.TombstonedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.LikeCount,
+		&i.Entities,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ViewCount,
+		&i.FlaggedAt,
+		&i.FlagReason,
+		&i.HiddenAt,
 	)
 	return i, err
 }
 
-const getChirps = `-- name: GetChirps :many
-select id, created_at, updated_at, body, user_id from chirps order by created_at asc
+const getChirpParentId = `-- name: GetChirpParentId :one
+select parent_chirp_id from chirps where id = $1
 `
 
-func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirps)
+func (q *Queries) GetChirpParentId(ctx context.Context, id uuid.UUID) (uuid.NullUUID, error) {
+	row := q.db.QueryRowContext(ctx, getChirpParentId, id)
+	var parent_chirp_id uuid.NullUUID
+	err := row.Scan(&parent_chirp_id)
+	return parent_chirp_id, err
+}
+
+const getChirpsByIds = `-- name: GetChirpsByIds :many
+select id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, root_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quote_of_id, rechirp_count, quote_count, like_count, entities, edited_at, deleted_at, publish_at, visibility, content_warning, sensitive, view_count, flagged_at, flag_reason, hidden_at from chirps
+where id = any($1::uuid[])
+  and deleted_at is null
+  and (publish_at is null or publish_at <= now())
+  and chirp_visible_to(user_id, visibility, entities, flagged_at, $2::uuid)
+`
+
+type GetChirpsByIdsParams struct {
+	Ids      []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetChirpsByIds(ctx context.Context, arg GetChirpsByIdsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIds, pq.Array(arg.Ids), arg.ViewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +417,27 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.ParentChirpID,
+			&i.RootChirpID,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.LikeCount,
+			&i.Entities,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ViewCount,
+			&i.FlaggedAt,
+			&i.FlagReason,
+			&i.HiddenAt,
 		); err != nil {
 			return nil, err
 		}
@@ -99,12 +452,172 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 	return items, nil
 }
 
-const getChirpsByUserId = `-- name: GetChirpsByUserId :many
-select id, created_at, updated_at, body, user_id from chirps where user_id = $1 order by created_at asc
+const getDeletedChirpById = `-- name: GetDeletedChirpById :one
+select id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, root_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quote_of_id, rechirp_count, quote_count, like_count, entities, edited_at, deleted_at, publish_at, visibility, content_warning, sensitive, view_count, flagged_at, flag_reason, hidden_at from chirps where id = $1 and deleted_at is not null and tombstoned_at is null and hidden_at is null
+`
+
+func (q *Queries) GetDeletedChirpById(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getDeletedChirpById, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ParentChirpID,
+		&i.RootChirpID,
+		&i.ReplyCount,
+		&i.TombstonedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.LikeCount,
+		&i.Entities,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ViewCount,
+		&i.FlaggedAt,
+		&i.FlagReason,
+		&i.HiddenAt,
+	)
+	return i, err
+}
+
+const getPinnedChirp = `-- name: GetPinnedChirp :one
+select chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.parent_chirp_id, chirps.root_chirp_id, chirps.reply_count, chirps.tombstoned_at, chirps.rechirp_of_id, chirps.quote_of_id, chirps.rechirp_count, chirps.quote_count, chirps.like_count, chirps.entities, chirps.edited_at, chirps.deleted_at, chirps.publish_at, chirps.visibility, chirps.content_warning, chirps.sensitive, chirps.view_count, chirps.flagged_at, chirps.flag_reason, chirps.hidden_at from users
+join chirps on chirps.id = users.pinned_chirp_id
+where users.id = $1
+  and chirps.deleted_at is null
+  and (chirps.publish_at is null or chirps.publish_at <= now())
+  and chirp_visible_to(chirps.user_id, chirps.visibility, chirps.entities, chirps.flagged_at, $2::uuid)
 `
 
-func (q *Queries) GetChirpsByUserId(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsByUserId, userID)
+type GetPinnedChirpParams struct {
+	UserID   uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetPinnedChirp(ctx context.Context, arg GetPinnedChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getPinnedChirp, arg.UserID, arg.ViewerID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ParentChirpID,
+		&i.RootChirpID,
+		&i.ReplyCount,
+		&i.TombstonedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.LikeCount,
+		&i.Entities,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ViewCount,
+		&i.FlaggedAt,
+		&i.FlagReason,
+		&i.HiddenAt,
+	)
+	return i, err
+}
+
+const hideChirp = `-- name: HideChirp :exec
+update chirps
+set hidden_at = coalesce(hidden_at, now()), deleted_at = coalesce(deleted_at, now()), updated_at = now()
+where id = $1
+`
+
+// Hidden chirps are deleted for everyone, and their authors cannot restore
+// them. A chirp the author already deleted keeps its delete time.
+func (q *Queries) HideChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, hideChirp, id)
+	return err
+}
+
+const incrementQuoteCount = `-- name: IncrementQuoteCount :exec
+update chirps set quote_count = quote_count + 1 where id = $1
+`
+
+func (q *Queries) IncrementQuoteCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, incrementQuoteCount, id)
+	return err
+}
+
+const incrementRechirpCount = `-- name: IncrementRechirpCount :exec
+update chirps set rechirp_count = rechirp_count + 1 where id = $1
+`
+
+func (q *Queries) IncrementRechirpCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, incrementRechirpCount, id)
+	return err
+}
+
+const incrementReplyCount = `-- name: IncrementReplyCount :exec
+update chirps set reply_count = reply_count + 1 where id = $1
+`
+
+func (q *Queries) IncrementReplyCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, incrementReplyCount, id)
+	return err
+}
+
+const listChirpsAsc = `-- name: ListChirpsAsc :many
+select id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, root_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quote_of_id, rechirp_count, quote_count, like_count, entities, edited_at, deleted_at, publish_at, visibility, content_warning, sensitive, view_count, flagged_at, flag_reason, hidden_at from chirps
+where deleted_at is null
+  and (publish_at is null or publish_at <= now())
+  and chirp_visible_to(user_id, visibility, entities, flagged_at, $1::uuid)
+  and (chirps.rechirp_of_id is null or exists (
+        select 1 from chirps original
+        where original.id = chirps.rechirp_of_id
+          and original.deleted_at is null
+          and (original.publish_at is null or original.publish_at <= now())
+          and chirp_visible_to(original.user_id, original.visibility, original.entities, original.flagged_at, $1::uuid)))
+  and (coalesce(cardinality($2::uuid[]), 0) = 0 or user_id = any($2::uuid[]))
+  and ($3::timestamp is null or created_at >= $3::timestamp)
+  and ($4::timestamp is null or created_at < $4::timestamp)
+  and (not $5::bool
+       or not (sensitive or exists (select 1 from chirps original where original.id = chirps.rechirp_of_id and original.sensitive))
+       or user_id = $1::uuid)
+  and ($6::timestamp is null
+       or (created_at, id) > ($6::timestamp, $7::uuid))
+order by created_at asc, id asc
+limit $8
+`
+
+type ListChirpsAscParams struct {
+	ViewerID       uuid.NullUUID
+	AuthorIds      []uuid.UUID
+	Since          sql.NullTime
+	Until          sql.NullTime
+	HideSensitive  bool
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	RowLimit       int32
+}
+
+// Each sort direction gets its own query so Postgres can walk the
+// (created_at, id) index forwards or backwards. Rechirps are skipped while
+// the viewer cannot see the original, which for a hidden original is for
+// good.
+func (q *Queries) ListChirpsAsc(ctx context.Context, arg ListChirpsAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsAsc, arg.ViewerID, pq.Array(arg.AuthorIds), arg.Since, arg.Until, arg.HideSensitive, arg.AfterCreatedAt, arg.AfterID, arg.RowLimit)
 	if err != nil {
 		return nil, err
 	}
@@ -118,6 +631,27 @@ func (q *Queries) GetChirpsByUserId(ctx context.Context, userID uuid.UUID) ([]Ch
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.ParentChirpID,
+			&i.RootChirpID,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.LikeCount,
+			&i.Entities,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ViewCount,
+			&i.FlaggedAt,
+			&i.FlagReason,
+			&i.HiddenAt,
 		); err != nil {
 			return nil, err
 		}
@@ -131,3 +665,766 @@ func (q *Queries) GetChirpsByUserId(ctx context.Context, userID uuid.UUID) ([]Ch
 	}
 	return items, nil
 }
+
+const listChirpsDesc = `-- name: ListChirpsDesc :many
+select id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, root_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quote_of_id, rechirp_count, quote_count, like_count, entities, edited_at, deleted_at, publish_at, visibility, content_warning, sensitive, view_count, flagged_at, flag_reason, hidden_at from chirps
+where deleted_at is null
+  and (publish_at is null or publish_at <= now())
+  and chirp_visible_to(user_id, visibility, entities, flagged_at, $1::uuid)
+  and (chirps.rechirp_of_id is null or exists (
+        select 1 from chirps original
+        where original.id = chirps.rechirp_of_id
+          and original.deleted_at is null
+          and (original.publish_at is null or original.publish_at <= now())
+          and chirp_visible_to(original.user_id, original.visibility, original.entities, original.flagged_at, $1::uuid)))
+  and (coalesce(cardinality($2::uuid[]), 0) = 0 or user_id = any($2::uuid[]))
+  and ($3::timestamp is null or created_at >= $3::timestamp)
+  and ($4::timestamp is null or created_at < $4::timestamp)
+  and (not $5::bool
+       or not (sensitive or exists (select 1 from chirps original where original.id = chirps.rechirp_of_id and original.sensitive))
+       or user_id = $1::uuid)
+  and ($6::timestamp is null
+       or (created_at, id) < ($6::timestamp, $7::uuid))
+order by created_at desc, id desc
+limit $8
+`
+
+type ListChirpsDescParams struct {
+	ViewerID       uuid.NullUUID
+	AuthorIds      []uuid.UUID
+	Since          sql.NullTime
+	Until          sql.NullTime
+	HideSensitive  bool
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	RowLimit       int32
+}
+
+func (q *Queries) ListChirpsDesc(ctx context.Context, arg ListChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsDesc, arg.ViewerID, pq.Array(arg.AuthorIds), arg.Since, arg.Until, arg.HideSensitive, arg.AfterCreatedAt, arg.AfterID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentChirpID,
+			&i.RootChirpID,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.LikeCount,
+			&i.Entities,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ViewCount,
+			&i.FlaggedAt,
+			&i.FlagReason,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFlaggedChirps = `-- name: ListFlaggedChirps :many
+select id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, root_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quote_of_id, rechirp_count, quote_count, like_count, entities, edited_at, deleted_at, publish_at, visibility, content_warning, sensitive, view_count, flagged_at, flag_reason, hidden_at from chirps
+where flagged_at is not null
+  and deleted_at is null
+  and ($1::timestamp is null
+       or (flagged_at, id) < ($1::timestamp, $2::uuid))
+order by flagged_at desc, id desc
+limit $3
+`
+
+type ListFlaggedChirpsParams struct {
+	AfterFlaggedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	RowLimit       int32
+}
+
+func (q *Queries) ListFlaggedChirps(ctx context.Context, arg ListFlaggedChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listFlaggedChirps, arg.AfterFlaggedAt, arg.AfterID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentChirpID,
+			&i.RootChirpID,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.LikeCount,
+			&i.Entities,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ViewCount,
+			&i.FlaggedAt,
+			&i.FlagReason,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingChirps = `-- name: ListPendingChirps :many
+select id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, root_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quote_of_id, rechirp_count, quote_count, like_count, entities, edited_at, deleted_at, publish_at, visibility, content_warning, sensitive, view_count, flagged_at, flag_reason, hidden_at from chirps
+where user_id = $1 and deleted_at is null and publish_at > now()
+order by publish_at asc, id asc
+`
+
+func (q *Queries) ListPendingChirps(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listPendingChirps, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentChirpID,
+			&i.RootChirpID,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.LikeCount,
+			&i.Entities,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ViewCount,
+			&i.FlaggedAt,
+			&i.FlagReason,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecentChirpBodies = `-- name: ListRecentChirpBodies :many
+select id, body, parent_chirp_id from chirps
+where user_id = $1
+  and created_at >= $2
+  and rechirp_of_id is null
+  and deleted_at is null
+`
+
+type ListRecentChirpBodiesParams struct {
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type ListRecentChirpBodiesRow struct {
+	ID            uuid.UUID
+	Body          string
+	ParentChirpID uuid.NullUUID
+}
+
+// Original chirps, replies and quotes an author posted since a time, for
+// duplicate detection.
+func (q *Queries) ListRecentChirpBodies(ctx context.Context, arg ListRecentChirpBodiesParams) ([]ListRecentChirpBodiesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listRecentChirpBodies, arg.UserID, arg.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListRecentChirpBodiesRow
+	for rows.Next() {
+		var i ListRecentChirpBodiesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.ParentChirpID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReplies = `-- name: ListReplies :many
+select id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, root_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quote_of_id, rechirp_count, quote_count, like_count, entities, edited_at, deleted_at, publish_at, visibility, content_warning, sensitive, view_count, flagged_at, flag_reason, hidden_at from chirps
+where parent_chirp_id = $1
+  and (deleted_at is null or exists (select 1 from chirps below where below.parent_chirp_id = chirps.id))
+  and (publish_at is null or publish_at <= now())
+  and chirp_visible_to(user_id, visibility, entities, flagged_at, $2::uuid)
+  and ($3::timestamp is null
+       or (created_at, id) > ($3::timestamp, $4::uuid))
+order by created_at asc, id asc
+limit $5
+`
+
+type ListRepliesParams struct {
+	ParentChirpID  uuid.UUID
+	ViewerID       uuid.NullUUID
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	RowLimit       int32
+}
+
+// Deleted replies stay as placeholders while anything still replies to them.
+// That is checked directly rather than through reply_count, which deletes
+// lower down the thread also decrement.
+func (q *Queries) ListReplies(ctx context.Context, arg ListRepliesParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listReplies, arg.ParentChirpID, arg.ViewerID, arg.AfterCreatedAt, arg.AfterID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentChirpID,
+			&i.RootChirpID,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.LikeCount,
+			&i.Entities,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ViewCount,
+			&i.FlaggedAt,
+			&i.FlagReason,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRepliesForParents = `-- name: ListRepliesForParents :many
+select id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, root_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quote_of_id, rechirp_count, quote_count, like_count, entities, edited_at, deleted_at, publish_at, visibility, content_warning, sensitive, view_count, flagged_at, flag_reason, hidden_at from chirps
+where id in (
+    select ranked.id from (
+        select replies.id,
+               row_number() over (partition by replies.parent_chirp_id order by replies.created_at, replies.id) as reply_rank
+        from chirps replies
+        where replies.parent_chirp_id = any($1::uuid[])
+          and (replies.deleted_at is null or exists (select 1 from chirps below where below.parent_chirp_id = replies.id))
+          and (replies.publish_at is null or replies.publish_at <= now())
+          and chirp_visible_to(replies.user_id, replies.visibility, replies.entities, replies.flagged_at, $2::uuid)
+    ) ranked
+    where ranked.reply_rank <= $3::bigint
+)
+order by parent_chirp_id, created_at asc, id asc
+`
+
+type ListRepliesForParentsParams struct {
+	ParentIds      []uuid.UUID
+	ViewerID       uuid.NullUUID
+	PerParentLimit int64
+}
+
+// Returns at most per_parent_limit replies for each parent, oldest first.
+func (q *Queries) ListRepliesForParents(ctx context.Context, arg ListRepliesForParentsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listRepliesForParents, pq.Array(arg.ParentIds), arg.ViewerID, arg.PerParentLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentChirpID,
+			&i.RootChirpID,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.LikeCount,
+			&i.Entities,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ViewCount,
+			&i.FlaggedAt,
+			&i.FlagReason,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeExpiredChirps = `-- name: PurgeExpiredChirps :execrows
+delete from chirps
+where deleted_at < $1::timestamp
+  and hidden_at is null
+  and not exists (select 1 from reports where reports.chirp_id = chirps.id and reports.status <> 'resolved')
+  and not exists (select 1 from chirps replies where replies.parent_chirp_id = chirps.id)
+`
+
+// Tombstones go too once the last of their replies has been purged. Hidden
+// chirps are never purged, and reported ones not until every report is
+// resolved, since the reports would cascade away with them.
+func (q *Queries) PurgeExpiredChirps(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeExpiredChirps, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const rescheduleChirp = `-- name: RescheduleChirp :one
+update chirps set publish_at = $3, created_at = $3, updated_at = now()
+where id = $1 and user_id = $2 and deleted_at is null and publish_at > now()
+returning id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, root_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quote_of_id, rechirp_count, quote_count, like_count, entities, edited_at, deleted_at, publish_at, visibility, content_warning, sensitive, view_count, flagged_at, flag_reason, hidden_at
+`
+
+type RescheduleChirpParams struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	PublishAt sql.NullTime
+}
+
+func (q *Queries) RescheduleChirp(ctx context.Context, arg RescheduleChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, rescheduleChirp, arg.ID, arg.UserID, arg.PublishAt)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ParentChirpID,
+		&i.RootChirpID,
+		&i.ReplyCount,
+		&i.TombstonedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.LikeCount,
+		&i.Entities,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ViewCount,
+		&i.FlaggedAt,
+		&i.FlagReason,
+		&i.HiddenAt,
+	)
+	return i, err
+}
+
+const restoreChirp = `-- name: RestoreChirp :execrows
+update chirps set deleted_at = null, updated_at = now()
+where id = $1 and user_id = $2 and deleted_at is not null and tombstoned_at is null and hidden_at is null
+`
+
+type RestoreChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) RestoreChirp(ctx context.Context, arg RestoreChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreChirp, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const searchChirpsByRank = `-- name: SearchChirpsByRank :many
+select id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, root_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quote_of_id, rechirp_count, quote_count, like_count, entities, edited_at, deleted_at, publish_at, visibility, content_warning, sensitive, view_count, flagged_at, flag_reason, hidden_at from chirps
+where deleted_at is null
+  and (publish_at is null or publish_at <= now())
+  and chirp_visible_to(user_id, visibility, entities, flagged_at, $1::uuid)
+  and (chirps.rechirp_of_id is null or exists (
+        select 1 from chirps original
+        where original.id = chirps.rechirp_of_id
+          and original.deleted_at is null
+          and (original.publish_at is null or original.publish_at <= now())
+          and chirp_visible_to(original.user_id, original.visibility, original.entities, original.flagged_at, $1::uuid)))
+  and search_vector @@ to_tsquery('english', $2)
+  and (coalesce(cardinality($3::uuid[]), 0) = 0 or user_id = any($3::uuid[]))
+order by ts_rank_cd(search_vector, to_tsquery('english', $2)) desc, created_at desc, id desc
+limit $4
+`
+
+type SearchChirpsByRankParams struct {
+	ViewerID  uuid.NullUUID
+	Query     string
+	AuthorIds []uuid.UUID
+	RowLimit  int32
+}
+
+func (q *Queries) SearchChirpsByRank(ctx context.Context, arg SearchChirpsByRankParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirpsByRank, arg.ViewerID, arg.Query, pq.Array(arg.AuthorIds), arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentChirpID,
+			&i.RootChirpID,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.LikeCount,
+			&i.Entities,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ViewCount,
+			&i.FlaggedAt,
+			&i.FlagReason,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchChirpsByRecency = `-- name: SearchChirpsByRecency :many
+select id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, root_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quote_of_id, rechirp_count, quote_count, like_count, entities, edited_at, deleted_at, publish_at, visibility, content_warning, sensitive, view_count, flagged_at, flag_reason, hidden_at from chirps
+where deleted_at is null
+  and (publish_at is null or publish_at <= now())
+  and chirp_visible_to(user_id, visibility, entities, flagged_at, $1::uuid)
+  and (chirps.rechirp_of_id is null or exists (
+        select 1 from chirps original
+        where original.id = chirps.rechirp_of_id
+          and original.deleted_at is null
+          and (original.publish_at is null or original.publish_at <= now())
+          and chirp_visible_to(original.user_id, original.visibility, original.entities, original.flagged_at, $1::uuid)))
+  and search_vector @@ to_tsquery('english', $2)
+  and (coalesce(cardinality($3::uuid[]), 0) = 0 or user_id = any($3::uuid[]))
+  and ($4::timestamp is null
+       or (created_at, id) < ($4::timestamp, $5::uuid))
+order by created_at desc, id desc
+limit $6
+`
+
+type SearchChirpsByRecencyParams struct {
+	ViewerID       uuid.NullUUID
+	Query          string
+	AuthorIds      []uuid.UUID
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	RowLimit       int32
+}
+
+func (q *Queries) SearchChirpsByRecency(ctx context.Context, arg SearchChirpsByRecencyParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirpsByRecency, arg.ViewerID, arg.Query, pq.Array(arg.AuthorIds), arg.AfterCreatedAt, arg.AfterID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentChirpID,
+			&i.RootChirpID,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.LikeCount,
+			&i.Entities,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ViewCount,
+			&i.FlaggedAt,
+			&i.FlagReason,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setChirpSensitive = `-- name: SetChirpSensitive :one
+update chirps set sensitive = $2
+where id = $1 and deleted_at is null
+returning id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, root_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quote_of_id, rechirp_count, quote_count, like_count, entities, edited_at, deleted_at, publish_at, visibility, content_warning, sensitive, view_count, flagged_at, flag_reason, hidden_at
+`
+
+type SetChirpSensitiveParams struct {
+	ID        uuid.UUID
+	Sensitive bool
+}
+
+func (q *Queries) SetChirpSensitive(ctx context.Context, arg SetChirpSensitiveParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, setChirpSensitive, arg.ID, arg.Sensitive)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ParentChirpID,
+		&i.RootChirpID,
+		&i.ReplyCount,
+		&i.TombstonedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.LikeCount,
+		&i.Entities,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ViewCount,
+		&i.FlaggedAt,
+		&i.FlagReason,
+		&i.HiddenAt,
+	)
+	return i, err
+}
+
+const tombstoneExpiredChirps = `-- name: TombstoneExpiredChirps :many
+update chirps set body = '', entities = '{}', tombstoned_at = now(), updated_at = now()
+where deleted_at < $1::timestamp
+  and tombstoned_at is null
+  and hidden_at is null
+  and not exists (select 1 from reports where reports.chirp_id = chirps.id and reports.status <> 'resolved')
+  and exists (select 1 from chirps replies where replies.parent_chirp_id = chirps.id)
+returning id
+`
+
+// Expired chirps that still have replies are scrubbed instead of deleted so
+// the thread keeps its shape. Hidden chirps, and chirps with reports still
+// waiting on a moderator, are kept whole here and in PurgeExpiredChirps.
+func (q *Queries) TombstoneExpiredChirps(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, tombstoneExpiredChirps, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+update chirps set body = $2, entities = $3, edited_at = now(), updated_at = now()
+where id = $1
+returning id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, root_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quote_of_id, rechirp_count, quote_count, like_count, entities, edited_at, deleted_at, publish_at, visibility, content_warning, sensitive, view_count, flagged_at, flag_reason, hidden_at
+`
+
+type UpdateChirpBodyParams struct {
+	ID       uuid.UUID
+	Body     string
+	Entities json.RawMessage
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.ID, arg.Body, arg.Entities)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ParentChirpID,
+		&i.RootChirpID,
+		&i.ReplyCount,
+		&i.TombstonedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.LikeCount,
+		&i.Entities,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ViewCount,
+		&i.FlaggedAt,
+		&i.FlagReason,
+		&i.HiddenAt,
+	)
+	return i, err
+}
@@ -6,17 +6,141 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-type Chirp struct {
+type BannedWord struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Body      string
+	Word      string
+	Action    string
+}
+
+type Bookmark struct {
 	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Chirp struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Body           string
+	UserID         uuid.UUID
+	SearchVector   interface{}
+	ParentChirpID  uuid.NullUUID
+	RootChirpID    uuid.NullUUID
+	ReplyCount     int32
+	TombstonedAt   sql.NullTime
+	RechirpOfID    uuid.NullUUID
+	QuoteOfID      uuid.NullUUID
+	RechirpCount   int32
+	QuoteCount     int32
+	LikeCount      int32
+	Entities       json.RawMessage
+	EditedAt       sql.NullTime
+	DeletedAt      sql.NullTime
+	PublishAt      sql.NullTime
+	Visibility     string
+	ContentWarning string
+	Sensitive      bool
+	ViewCount      int64
+	FlaggedAt      sql.NullTime
+	FlagReason     string
+	HiddenAt       sql.NullTime
+}
+
+type ChirpDraft struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	UserID        uuid.UUID
+	Body          string
+	ParentChirpID uuid.NullUUID
+}
+
+type ChirpHashtag struct {
+	ChirpID   uuid.UUID
+	HashtagID uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpLike struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpRevision struct {
+	ID         uuid.UUID
+	ChirpID    uuid.UUID
+	Body       string
+	Entities   json.RawMessage
+	CreatedAt  time.Time
+	ReplacedAt time.Time
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Hashtag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Tag       string
+}
+
+type Medium struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UserID       uuid.UUID
+	ChirpID      uuid.NullUUID
+	Position     int32
+	ContentType  string
+	StorageKey   string
+	ThumbnailKey string
+	Width        int32
+	Height       int32
+	AltText      string
+}
+
+type ModerationLog struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	ActorID   uuid.NullUUID
+	Action    string
+	ReportID  uuid.NullUUID
+	ChirpID   uuid.NullUUID
+	UserID    uuid.NullUUID
+	Note      string
+}
+
+type Poll struct {
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+	ClosesAt  time.Time
+}
+
+type PollOption struct {
+	ID        uuid.UUID
+	ChirpID   uuid.UUID
+	Position  int32
+	Label     string
+	VoteCount int32
+}
+
+type PollVote struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	OptionID  uuid.UUID
+	CreatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -28,6 +152,21 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
+type Report struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	ChirpID    uuid.UUID
+	ReporterID uuid.UUID
+	Reason     string
+	Details    string
+	Status     string
+	ClaimedBy  uuid.NullUUID
+	ClaimedAt  sql.NullTime
+	Resolution sql.NullString
+	ResolvedAt sql.NullTime
+}
+
 type User struct {
 	ID             uuid.UUID
 	CreatedAt      time.Time
@@ -35,4 +174,9 @@ type User struct {
 	Email          string
 	HashedPassword string
 	IsChirpyRed    bool
+	PinnedChirpID  uuid.NullUUID
+	SensitiveMedia string
+	SuspendedAt    sql.NullTime
+	Role           string
+	Username       string
 }
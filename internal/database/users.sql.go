@@ -7,25 +7,28 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (created_at, updated_at, email, hashed_password)
+INSERT INTO users (created_at, updated_at, email, hashed_password, username)
 VALUES (
-    now(), now(), $1, $2
+    now(), now(), $1, $2, $3
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, pinned_chirp_id, sensitive_media, suspended_at, role, username
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword string
+	Username       string
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword, arg.Username)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -34,6 +37,11 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.PinnedChirpID,
+		&i.SensitiveMedia,
+		&i.SuspendedAt,
+		&i.Role,
+		&i.Username,
 	)
 	return i, err
 }
@@ -48,7 +56,7 @@ func (q *Queries) DeleteUsers(ctx context.Context) error {
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-Select id, created_at, updated_at, email, hashed_password, is_chirpy_red from users where email = $1
+Select id, created_at, updated_at, email, hashed_password, is_chirpy_red, pinned_chirp_id, sensitive_media, suspended_at, role, username from users where email = $1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -61,12 +69,17 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.PinnedChirpID,
+		&i.SensitiveMedia,
+		&i.SuspendedAt,
+		&i.Role,
+		&i.Username,
 	)
 	return i, err
 }
 
 const getUserById = `-- name: GetUserById :one
-Select id, created_at, updated_at, email, hashed_password, is_chirpy_red from users where id = $1
+Select id, created_at, updated_at, email, hashed_password, is_chirpy_red, pinned_chirp_id, sensitive_media, suspended_at, role, username from users where id = $1
 `
 
 func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
@@ -79,25 +92,171 @@ func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.PinnedChirpID,
+		&i.SensitiveMedia,
+		&i.SuspendedAt,
+		&i.Role,
+		&i.Username,
+	)
+	return i, err
+}
+
+const getUserIdsByUsernames = `-- name: GetUserIdsByUsernames :many
+Select id, username from users where username = any($1::text[])
+`
+
+type GetUserIdsByUsernamesRow struct {
+	ID       uuid.UUID
+	Username string
+}
+
+func (q *Queries) GetUserIdsByUsernames(ctx context.Context, usernames []string) ([]GetUserIdsByUsernamesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserIdsByUsernames, pq.Array(usernames))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserIdsByUsernamesRow
+	for rows.Next() {
+		var i GetUserIdsByUsernamesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const pinChirp = `-- name: PinChirp :exec
+Update users set pinned_chirp_id = $2, updated_at = now()
+where id = $1
+`
+
+type PinChirpParams struct {
+	ID            uuid.UUID
+	PinnedChirpID uuid.NullUUID
+}
+
+func (q *Queries) PinChirp(ctx context.Context, arg PinChirpParams) error {
+	_, err := q.db.ExecContext(ctx, pinChirp, arg.ID, arg.PinnedChirpID)
+	return err
+}
+
+const setUserRole = `-- name: SetUserRole :one
+Update users set role = $2, updated_at = now()
+where id = $1
+returning id, created_at, updated_at, email, hashed_password, is_chirpy_red, pinned_chirp_id, sensitive_media, suspended_at, role, username
+`
+
+type SetUserRoleParams struct {
+	ID   uuid.UUID
+	Role string
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserRole, arg.ID, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.PinnedChirpID,
+		&i.SensitiveMedia,
+		&i.SuspendedAt,
+		&i.Role,
+		&i.Username,
+	)
+	return i, err
+}
+
+const suspendUser = `-- name: SuspendUser :exec
+Update users set suspended_at = coalesce(suspended_at, now()), updated_at = now()
+where id = $1
+`
+
+func (q *Queries) SuspendUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, suspendUser, id)
+	return err
+}
+
+const unpinChirp = `-- name: UnpinChirp :execrows
+Update users set pinned_chirp_id = null, updated_at = now()
+where id = $1 and pinned_chirp_id = $2
+`
+
+type UnpinChirpParams struct {
+	ID            uuid.UUID
+	PinnedChirpID uuid.NullUUID
+}
+
+func (q *Queries) UnpinChirp(ctx context.Context, arg UnpinChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unpinChirp, arg.ID, arg.PinnedChirpID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateSensitiveMediaPreference = `-- name: UpdateSensitiveMediaPreference :one
+Update users set sensitive_media = $2, updated_at = now()
+where id = $1
+returning id, created_at, updated_at, email, hashed_password, is_chirpy_red, pinned_chirp_id, sensitive_media, suspended_at, role, username
+`
+
+type UpdateSensitiveMediaPreferenceParams struct {
+	ID             uuid.UUID
+	SensitiveMedia string
+}
+
+func (q *Queries) UpdateSensitiveMediaPreference(ctx context.Context, arg UpdateSensitiveMediaPreferenceParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateSensitiveMediaPreference, arg.ID, arg.SensitiveMedia)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.PinnedChirpID,
+		&i.SensitiveMedia,
+		&i.SuspendedAt,
+		&i.Role,
+		&i.Username,
 	)
 	return i, err
 }
 
 const updateUserById = `-- name: UpdateUserById :one
-Update users set email = $2, hashed_password = $3,
+Update users set email = $1, hashed_password = $2,
+                 username = coalesce($3::text, username),
                  updated_at = now()
-where id = $1
-returning id, created_at, updated_at, email, hashed_password, is_chirpy_red
+where id = $4
+returning id, created_at, updated_at, email, hashed_password, is_chirpy_red, pinned_chirp_id, sensitive_media, suspended_at, role, username
 `
 
 type UpdateUserByIdParams struct {
-	ID             uuid.UUID
 	Email          string
 	HashedPassword string
+	Username       sql.NullString
+	ID             uuid.UUID
 }
 
+// The username is left alone when none is given.
 func (q *Queries) UpdateUserById(ctx context.Context, arg UpdateUserByIdParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateUserById, arg.ID, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, updateUserById, arg.Email, arg.HashedPassword, arg.Username, arg.ID)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -106,6 +265,11 @@ func (q *Queries) UpdateUserById(ctx context.Context, arg UpdateUserByIdParams)
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.PinnedChirpID,
+		&i.SensitiveMedia,
+		&i.SuspendedAt,
+		&i.Role,
+		&i.Username,
 	)
 	return i, err
 }
@@ -113,7 +277,7 @@ func (q *Queries) UpdateUserById(ctx context.Context, arg UpdateUserByIdParams)
 const upgradeUserById = `-- name: UpgradeUserById :one
 Update users set is_chirpy_red = true, updated_at = now()
 where id = $1
-returning id, created_at, updated_at, email, hashed_password, is_chirpy_red
+returning id, created_at, updated_at, email, hashed_password, is_chirpy_red, pinned_chirp_id, sensitive_media, suspended_at, role, username
 `
 
 func (q *Queries) UpgradeUserById(ctx context.Context, id uuid.UUID) (User, error) {
@@ -126,6 +290,11 @@ func (q *Queries) UpgradeUserById(ctx context.Context, id uuid.UUID) (User, erro
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.PinnedChirpID,
+		&i.SensitiveMedia,
+		&i.SuspendedAt,
+		&i.Role,
+		&i.Username,
 	)
 	return i, err
 }
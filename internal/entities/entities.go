@@ -0,0 +1,147 @@
+package entities
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Offsets are in Unicode code points: Start is inclusive and End exclusive.
+
+type Mention struct {
+	Handle string    `json:"handle"`
+	UserID uuid.UUID `json:"user_id"`
+	Start  int       `json:"start"`
+	End    int       `json:"end"`
+}
+
+type URL struct {
+	URL   string `json:"url"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+type Hashtag struct {
+	Tag   string `json:"tag"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+type Entities struct {
+	Mentions []Mention `json:"mentions,omitempty"`
+	URLs     []URL     `json:"urls,omitempty"`
+	Hashtags []Hashtag `json:"hashtags,omitempty"`
+}
+
+// Parse finds the mentions, URLs and hashtags in body. Mentions are
+// @username and come back unresolved, with a zero UserID; see
+// ResolveMentions.
+func Parse(body string) Entities {
+	var found Entities
+	runes := []rune(body)
+
+	for i := 0; i < len(runes); {
+		boundary := i == 0 || !isTagRune(runes[i-1])
+		if boundary {
+			if end := urlEnd(runes, i); end > i {
+				found.URLs = append(found.URLs, URL{URL: string(runes[i:end]), Start: i, End: end})
+				i = end
+				continue
+			}
+		}
+		switch {
+		case runes[i] == '#' && boundary:
+			end := i + 1
+			for end < len(runes) && isTagRune(runes[end]) {
+				end++
+			}
+			if tag, ok := NormalizeHashtag(string(runes[i+1 : end])); ok {
+				found.Hashtags = append(found.Hashtags, Hashtag{Tag: tag, Start: i, End: end})
+				i = end
+				continue
+			}
+		case runes[i] == '@' && boundary:
+			if end := mentionEnd(runes, i+1); end > i+1 {
+				handle, _ := NormalizeUsername(string(runes[i+1 : end]))
+				found.Mentions = append(found.Mentions, Mention{Handle: handle, Start: i, End: end})
+				i = end
+				continue
+			}
+		}
+		i++
+	}
+	return found
+}
+
+// ResolveMentions fills in user IDs from handles and drops mentions of
+// handles that do not belong to anyone.
+func (e *Entities) ResolveMentions(userIDs map[string]uuid.UUID) {
+	resolved := e.Mentions[:0]
+	for _, mention := range e.Mentions {
+		if userID, ok := userIDs[mention.Handle]; ok {
+			mention.UserID = userID
+			resolved = append(resolved, mention)
+		}
+	}
+	e.Mentions = resolved
+	if len(e.Mentions) == 0 {
+		e.Mentions = nil
+	}
+}
+
+// MentionHandles returns the distinct handles mentioned.
+func (e Entities) MentionHandles() []string {
+	var handles []string
+	seen := make(map[string]bool)
+	for _, mention := range e.Mentions {
+		if !seen[mention.Handle] {
+			seen[mention.Handle] = true
+			handles = append(handles, mention.Handle)
+		}
+	}
+	return handles
+}
+
+func urlEnd(runes []rune, start int) int {
+	rest := strings.ToLower(string(runes[start:min(start+8, len(runes))]))
+	schemeEnd := start
+	switch {
+	case strings.HasPrefix(rest, "https://"):
+		schemeEnd += len("https://")
+	case strings.HasPrefix(rest, "http://"):
+		schemeEnd += len("http://")
+	default:
+		return start
+	}
+
+	end := schemeEnd
+	for end < len(runes) && !unicode.IsSpace(runes[end]) {
+		end++
+	}
+	// Trailing punctuation usually belongs to the sentence, not the link.
+	for end > schemeEnd && strings.ContainsRune(".,;:!?'\")]", runes[end-1]) {
+		end--
+	}
+	if end == schemeEnd {
+		return start
+	}
+	return end
+}
+
+// mentionEnd scans a username starting at start and returns where it ends,
+// or start if there is none. A username running straight into an '@' is the
+// local part of an email address, not a mention.
+func mentionEnd(runes []rune, start int) int {
+	end := start
+	for end < len(runes) && isUsernameRune(runes[end]) {
+		end++
+	}
+	if end < len(runes) && (isTagRune(runes[end]) || runes[end] == '@') {
+		return start
+	}
+	if _, ok := NormalizeUsername(string(runes[start:end])); !ok {
+		return start
+	}
+	return end
+}
@@ -0,0 +1,78 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParse(t *testing.T) {
+	body := "Hi @Walt_White, see https://example.com/a#b. #Chirpy ☕ #fun"
+
+	got := Parse(body)
+
+	wantMentions := []Mention{{Handle: "walt_white", Start: 3, End: 14}}
+	if !reflect.DeepEqual(got.Mentions, wantMentions) {
+		t.Errorf("Expected mentions %+v, got %+v", wantMentions, got.Mentions)
+	}
+	wantURLs := []URL{{URL: "https://example.com/a#b", Start: 20, End: 43}}
+	if !reflect.DeepEqual(got.URLs, wantURLs) {
+		t.Errorf("Expected urls %+v, got %+v", wantURLs, got.URLs)
+	}
+	// The emoji is a single code point, so offsets after it only move by one.
+	wantHashtags := []Hashtag{{Tag: "chirpy", Start: 45, End: 52}, {Tag: "fun", Start: 55, End: 59}}
+	if !reflect.DeepEqual(got.Hashtags, wantHashtags) {
+		t.Errorf("Expected hashtags %+v, got %+v", wantHashtags, got.Hashtags)
+	}
+}
+
+func TestParse_NotEntities(t *testing.T) {
+	for _, body := range []string{
+		"email me at walt@breakingbad.com",
+		"@walt@breakingbad.com is an email, not a handle",
+		"@wa is too short",
+		"@walter_hartwell_white_heisenberg is too long",
+		"@walté is not a username",
+		"https:// is not a link",
+		"ftp://example.com is not supported",
+		"price#1",
+	} {
+		got := Parse(body)
+		if len(got.Mentions) != 0 || len(got.URLs) != 0 || len(got.Hashtags) != 0 {
+			t.Errorf("Expected no entities in %q, got %+v", body, got)
+		}
+	}
+}
+
+func TestResolveMentions(t *testing.T) {
+	walt := uuid.New()
+	found := Parse("@walt and @nobody and @WALT")
+
+	if handles := found.MentionHandles(); len(handles) != 2 {
+		t.Fatalf("Expected two distinct handles, got %v", handles)
+	}
+
+	found.ResolveMentions(map[string]uuid.UUID{"walt": walt})
+	if len(found.Mentions) != 2 {
+		t.Fatalf("Expected two resolved mentions, got %+v", found.Mentions)
+	}
+	for _, mention := range found.Mentions {
+		if mention.UserID != walt {
+			t.Errorf("Expected user %v, got %v", walt, mention.UserID)
+		}
+	}
+}
+
+func TestNormalizeUsername(t *testing.T) {
+	for name, want := range map[string]string{"@Walt_White": "walt_white", "abc": "abc", "a1_": "a1_"} {
+		if got, ok := NormalizeUsername(name); !ok || got != want {
+			t.Errorf("NormalizeUsername(%q) = %q, %v; want %q", name, got, ok, want)
+		}
+	}
+	for _, name := range []string{"", "ab", "walt white", "walt.white", "wältér", "walt@example.com", "abcdefghijklmnopqrstuvwxyz12345"} {
+		if got, ok := NormalizeUsername(name); ok {
+			t.Errorf("NormalizeUsername(%q) = %q, want invalid", name, got)
+		}
+	}
+}
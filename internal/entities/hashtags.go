@@ -0,0 +1,51 @@
+package entities
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxHashtagLength is the longest tag, in characters, that gets recognised.
+const MaxHashtagLength = 100
+
+// Hashtags returns the distinct tags in body, normalised with NormalizeHashtag,
+// in order of first use.
+func Hashtags(body string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, hashtag := range Parse(body).Hashtags {
+		if !seen[hashtag.Tag] {
+			seen[hashtag.Tag] = true
+			tags = append(tags, hashtag.Tag)
+		}
+	}
+	return tags
+}
+
+// NormalizeHashtag lowercases a tag without its leading '#' and reports
+// whether it is a valid hashtag: letters, digits and underscores, with at
+// least one letter.
+func NormalizeHashtag(tag string) (string, bool) {
+	tag = strings.TrimPrefix(tag, "#")
+	if tag == "" || utf8.RuneCountInString(tag) > MaxHashtagLength {
+		return "", false
+	}
+	hasLetter := false
+	for _, r := range tag {
+		if !isTagRune(r) {
+			return "", false
+		}
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		}
+	}
+	if !hasLetter {
+		return "", false
+	}
+	return strings.ToLower(tag), true
+}
+
+func isTagRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
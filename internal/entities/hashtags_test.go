@@ -0,0 +1,41 @@
+package entities
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHashtags(t *testing.T) {
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{"no tags here", nil},
+		{"#Go is fun", []string{"go"}},
+		{"loving #golang and #GoLang again", []string{"golang"}},
+		{"#one,#two.#three!", []string{"one", "two", "three"}},
+		{"issue#42 and #42 are not tags", nil},
+		{"snake #tag_case works", []string{"tag_case"}},
+		{"unicode #café and #日本", []string{"café", "日本"}},
+		{"## double", nil},
+	}
+
+	for _, tt := range tests {
+		got := Hashtags(tt.body)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Hashtags(%q) = %v, want %v", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeHashtag(t *testing.T) {
+	if tag, ok := NormalizeHashtag("#Chirpy"); !ok || tag != "chirpy" {
+		t.Errorf("Expected chirpy, got %q (%v)", tag, ok)
+	}
+	for _, input := range []string{"", "#", "123", "has space", "dash-tag", strings.Repeat("a", MaxHashtagLength+1)} {
+		if _, ok := NormalizeHashtag(input); ok {
+			t.Errorf("Expected %q to be rejected", input)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package entities
+
+import "github.com/rivo/uniseg"
+
+// URLLength is what every URL counts for toward a chirp's length, however
+// long the link actually is.
+const URLLength = 23
+
+// Length counts body the way readers see it: one per user-perceived
+// character (grapheme cluster), so emoji sequences and combining marks count
+// once, with every URL counted as URLLength.
+func Length(body string) int {
+	runes := []rune(body)
+	length := 0
+	start := 0
+	for _, url := range Parse(body).URLs {
+		length += uniseg.GraphemeClusterCount(string(runes[start:url.Start])) + URLLength
+		start = url.End
+	}
+	return length + uniseg.GraphemeClusterCount(string(runes[start:]))
+}
@@ -0,0 +1,34 @@
+package entities
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLength(t *testing.T) {
+	tests := map[string]struct {
+		body string
+		want int
+	}{
+		"empty":            {"", 0},
+		"ascii":            {"hello world", 11},
+		"accented":         {"café café", 9},
+		"combining mark":   {"cafe\u0301", 4},
+		"cjk":              {"你好世界", 4},
+		"emoji":            {"😀😀", 2},
+		"family emoji":     {"👨‍👩‍👧‍👦", 1},
+		"flag":             {"🇳🇱", 1},
+		"skin tone":        {"👍🏽 ok", 4},
+		"short url":        {"see https://go.dev", 4 + URLLength},
+		"long url":         {"https://example.com/" + strings.Repeat("a", 200), URLLength},
+		"urls and text":    {"a https://x.io b https://y.io", 5 + 2*URLLength},
+		"url then emoji":   {"https://x.io 😀", URLLength + 2},
+		"not a url":        {"example.com", 11},
+		"emoji before url": {"☕ https://example.com/menu", 2 + URLLength},
+	}
+	for name, tt := range tests {
+		if got := Length(tt.body); got != tt.want {
+			t.Errorf("%s: expected %d, got %d", name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package entities
+
+import "strings"
+
+// Usernames are the handles chirps mention users by.
+const (
+	MinUsernameLength = 3
+	MaxUsernameLength = 30
+)
+
+// NormalizeUsername lowercases a username without its leading '@' and
+// reports whether it is valid: ASCII letters, digits and underscores,
+// between MinUsernameLength and MaxUsernameLength long.
+func NormalizeUsername(name string) (string, bool) {
+	name = strings.TrimPrefix(name, "@")
+	if len(name) < MinUsernameLength || len(name) > MaxUsernameLength {
+		return "", false
+	}
+	for _, r := range name {
+		if !isUsernameRune(r) {
+			return "", false
+		}
+	}
+	return strings.ToLower(name), true
+}
+
+func isUsernameRune(r rune) bool {
+	return r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9'
+}
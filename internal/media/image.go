@@ -0,0 +1,169 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	MaxUploadBytes = 5 << 20
+	MaxDimension   = 8192
+	// MaxPixels caps the canvas as a whole. Compressed images are tiny next to
+	// their pixels, so this rather than MaxUploadBytes is what bounds the
+	// memory one upload can take.
+	MaxPixels     = 40_000_000
+	ThumbnailSize = 400
+	jpegQuality   = 90
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrInvalidImage    = errors.New("invalid image")
+	ErrImageTooLarge   = errors.New("image dimensions are too large")
+)
+
+// extensions lists the accepted content types and the key suffix each one is
+// stored under.
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+// Image is an upload after processing, ready to be stored.
+type Image struct {
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+	Data        []byte
+	Thumbnail   []byte
+}
+
+// Process checks an upload and re-encodes it along with a thumbnail. The
+// content type is sniffed from the bytes rather than trusted from the client.
+// Re-encoding drops EXIF and every other metadata block; the JPEG orientation
+// tag is applied to the pixels first so photos still display upright.
+func Process(data []byte) (Image, error) {
+	contentType := http.DetectContentType(data)
+	ext, ok := extensions[contentType]
+	if !ok {
+		return Image{}, ErrUnsupportedType
+	}
+	// Check the header before decoding so a tiny file cannot claim a huge
+	// canvas and exhaust memory.
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, ErrInvalidImage
+	}
+	if config.Width > MaxDimension || config.Height > MaxDimension || config.Width*config.Height > MaxPixels {
+		return Image{}, ErrImageTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, ErrInvalidImage
+	}
+	if contentType == "image/jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	full, err := encode(img, contentType)
+	if err != nil {
+		return Image{}, err
+	}
+	thumbnail, err := encode(Thumbnail(img, ThumbnailSize), contentType)
+	if err != nil {
+		return Image{}, err
+	}
+	bounds := img.Bounds()
+	return Image{
+		ContentType: contentType,
+		Ext:         ext,
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+		Data:        full,
+		Thumbnail:   thumbnail,
+	}, nil
+}
+
+func encode(img image.Image, contentType string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if contentType == "image/png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	return buf.Bytes(), err
+}
+
+// Thumbnail scales img down so its longer side is at most maxSize, averaging
+// each block of source pixels. Smaller images are returned unchanged.
+func Thumbnail(img image.Image, maxSize int) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW <= maxSize && srcH <= maxSize {
+		return img
+	}
+	dstW, dstH := maxSize, srcH*maxSize/srcW
+	if srcH > srcW {
+		dstW, dstH = srcW*maxSize/srcH, maxSize
+	}
+	dstW, dstH = max(dstW, 1), max(dstH, 1)
+
+	src := newRowReader(img)
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	sums := make([]uint64, 4*dstW)
+	for y := 0; y < dstH; y++ {
+		y0 := y * srcH / dstH
+		y1 := max((y+1)*srcH/dstH, y0+1)
+		clear(sums)
+		for sy := y0; sy < y1; sy++ {
+			row := src.row(bounds.Min.Y + sy)
+			for x := 0; x < dstW; x++ {
+				x0 := x * srcW / dstW
+				x1 := max((x+1)*srcW/dstW, x0+1)
+				for i := 4 * x0; i < 4*x1; i += 4 {
+					sums[4*x] += uint64(row[i])
+					sums[4*x+1] += uint64(row[i+1])
+					sums[4*x+2] += uint64(row[i+2])
+					sums[4*x+3] += uint64(row[i+3])
+				}
+			}
+		}
+		out := dst.Pix[y*dst.Stride:]
+		for x := 0; x < dstW; x++ {
+			x0 := x * srcW / dstW
+			x1 := max((x+1)*srcW/dstW, x0+1)
+			n := uint64((x1 - x0) * (y1 - y0))
+			for c := 0; c < 4; c++ {
+				out[4*x+c] = uint8(sums[4*x+c] / n)
+			}
+		}
+	}
+	return dst
+}
+
+// rowReader hands out one row of an image at a time as RGBA bytes. Going
+// through draw.Draw uses the standard library's fast paths for the types the
+// decoders return, instead of a color.Color per pixel, and never holds more
+// than one converted row.
+type rowReader struct {
+	img image.Image
+	buf *image.RGBA
+}
+
+func newRowReader(img image.Image) *rowReader {
+	return &rowReader{img: img, buf: image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), 1))}
+}
+
+// row returns row y, in img's coordinates, as premultiplied RGBA. The slice
+// is reused by the next call.
+func (r *rowReader) row(y int) []uint8 {
+	draw.Draw(r.buf, r.buf.Bounds(), r.img, image.Pt(r.img.Bounds().Min.X, y), draw.Src)
+	return r.buf.Pix
+}
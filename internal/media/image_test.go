@@ -0,0 +1,238 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// halves returns a w x h image that is red on the left and blue on the right.
+func halves(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// withExif inserts an APP1 segment carrying only an orientation tag right
+// after the JPEG start-of-image marker.
+func withExif(data []byte, orientation uint16) []byte {
+	tiff := []byte("II*\x00")
+	tiff = binary.LittleEndian.AppendUint32(tiff, 8)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, exifOrientationTag)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return buf.Bytes()
+}
+
+func isRed(c color.Color) bool {
+	r, _, b, _ := c.RGBA()
+	return r > 0xC000 && b < 0x4000
+}
+
+func TestProcess_StripsExifAndAppliesOrientation(t *testing.T) {
+	data := withExif(encodeJPEG(t, halves(32, 16)), 6)
+	if jpegOrientation(data) != 6 {
+		t.Fatalf("Expected orientation 6 in the test fixture, got %d", jpegOrientation(data))
+	}
+
+	processed, err := Process(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if processed.ContentType != "image/jpeg" || processed.Ext != ".jpg" {
+		t.Errorf("Expected a JPEG, got %s (%s)", processed.ContentType, processed.Ext)
+	}
+	if bytes.Contains(processed.Data, []byte("Exif")) {
+		t.Error("Expected EXIF to be stripped")
+	}
+	if processed.Width != 16 || processed.Height != 32 {
+		t.Fatalf("Expected 16x32 after rotation, got %dx%d", processed.Width, processed.Height)
+	}
+
+	rotated, err := jpeg.Decode(bytes.NewReader(processed.Data))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// Turning clockwise brings the red left half to the top.
+	if !isRed(rotated.At(8, 4)) || isRed(rotated.At(8, 28)) {
+		t.Error("Expected the red half on top after applying the orientation")
+	}
+}
+
+func TestProcess_PNGThumbnail(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, halves(1000, 500)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	processed, err := Process(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if processed.ContentType != "image/png" || processed.Width != 1000 || processed.Height != 500 {
+		t.Errorf("Expected a 1000x500 PNG, got %s %dx%d", processed.ContentType, processed.Width, processed.Height)
+	}
+	thumbnail, err := png.Decode(bytes.NewReader(processed.Thumbnail))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if size := thumbnail.Bounds().Size(); size.X != ThumbnailSize || size.Y != ThumbnailSize/2 {
+		t.Errorf("Expected a %dx%d thumbnail, got %v", ThumbnailSize, ThumbnailSize/2, size)
+	}
+	if !isRed(thumbnail.At(10, 10)) || isRed(thumbnail.At(ThumbnailSize-10, 10)) {
+		t.Error("Expected the thumbnail to keep the two halves")
+	}
+}
+
+// pngHeader returns just the signature and IHDR chunk of a w x h PNG, which
+// is all image.DecodeConfig reads.
+func pngHeader(w, h uint32) []byte {
+	ihdr := []byte("IHDR")
+	ihdr = binary.BigEndian.AppendUint32(ihdr, w)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, h)
+	ihdr = append(ihdr, 8, 0, 0, 0, 0)
+
+	out := []byte("\x89PNG\r\n\x1a\n")
+	out = binary.BigEndian.AppendUint32(out, uint32(len(ihdr)-4))
+	out = append(out, ihdr...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(ihdr))
+}
+
+func TestProcess_Rejects(t *testing.T) {
+	var huge bytes.Buffer
+	if err := png.Encode(&huge, image.NewGray(image.Rect(0, 0, MaxDimension+1, 1))); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := map[string]struct {
+		data []byte
+		want error
+	}{
+		"text":        {[]byte("just some text"), ErrUnsupportedType},
+		"gif":         {[]byte("GIF89a\x01\x00\x01\x00"), ErrUnsupportedType},
+		"truncated":   {[]byte("\xFF\xD8\xFF\xE0\x00\x10JFIF"), ErrInvalidImage},
+		"huge canvas": {huge.Bytes(), ErrImageTooLarge},
+		// Both sides are within MaxDimension, but not the pixel count.
+		"too many pixels": {pngHeader(MaxDimension, MaxPixels/MaxDimension+1), ErrImageTooLarge},
+	}
+	for name, tt := range tests {
+		if _, err := Process(tt.data); err != tt.want {
+			t.Errorf("%s: expected %v, got %v", name, tt.want, err)
+		}
+	}
+}
+
+func TestJpegOrientation_Malformed(t *testing.T) {
+	inputs := [][]byte{
+		nil,
+		[]byte("\xFF\xD8"),
+		[]byte("\xFF\xD8\xFF\xE1\xFF\xFFExif"),
+		[]byte("\xFF\xD8\xFF\xE1\x00\x10Exif\x00\x00XX*\x00\x08\x00\x00\x00"),
+	}
+	for _, input := range inputs {
+		if got := jpegOrientation(input); got != 1 {
+			t.Errorf("Expected orientation 1 for %q, got %d", input, got)
+		}
+	}
+}
+
+func TestThumbnail_DecoderTypes(t *testing.T) {
+	src := halves(800, 400)
+	nrgba := image.NewNRGBA(src.Bounds())
+	ycbcr := image.NewYCbCr(src.Bounds(), image.YCbCrSubsampleRatio420)
+	gray := image.NewGray(src.Bounds())
+	for y := 0; y < 400; y++ {
+		for x := 0; x < 800; x++ {
+			nrgba.Set(x, y, src.At(x, y))
+			r, g, b, _ := src.At(x, y).RGBA()
+			yy, cb, cr := color.RGBToYCbCr(uint8(r>>8), uint8(g>>8), uint8(b>>8))
+			ycbcr.Y[ycbcr.YOffset(x, y)] = yy
+			ycbcr.Cb[ycbcr.COffset(x, y)] = cb
+			ycbcr.Cr[ycbcr.COffset(x, y)] = cr
+		}
+	}
+
+	for name, img := range map[string]image.Image{"rgba": src, "nrgba": nrgba, "ycbcr": ycbcr} {
+		thumbnail := Thumbnail(img, 200)
+		if size := thumbnail.Bounds().Size(); size.X != 200 || size.Y != 100 {
+			t.Errorf("%s: expected a 200x100 thumbnail, got %v", name, size)
+		}
+		if !isRed(thumbnail.At(10, 50)) || isRed(thumbnail.At(190, 50)) {
+			t.Errorf("%s: expected the thumbnail to keep the two halves", name)
+		}
+	}
+
+	// A grey level averages to itself.
+	for i := range gray.Pix {
+		gray.Pix[i] = 0x80
+	}
+	if r, _, _, _ := Thumbnail(gray, 200).At(100, 50).RGBA(); r>>8 != 0x80 {
+		t.Errorf("expected grey 0x80 to stay 0x80, got %#x", r>>8)
+	}
+}
+
+func TestApplyOrientation_AllOrientations(t *testing.T) {
+	// Every pixel of a 3x2 image is distinct, so any misplaced one shows.
+	const w, h = 3, 2
+	src := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			src.Set(x, y, color.RGBA{R: uint8(x * 80), G: uint8(y * 80), A: 255})
+		}
+	}
+	// at reports which stored pixel shows up at (x, y), per the EXIF spec.
+	at := map[int]func(x, y int) (int, int){
+		2: func(x, y int) (int, int) { return w - 1 - x, y },
+		3: func(x, y int) (int, int) { return w - 1 - x, h - 1 - y },
+		4: func(x, y int) (int, int) { return x, h - 1 - y },
+		5: func(x, y int) (int, int) { return y, x },
+		6: func(x, y int) (int, int) { return y, h - 1 - x },
+		7: func(x, y int) (int, int) { return w - 1 - y, h - 1 - x },
+		8: func(x, y int) (int, int) { return w - 1 - y, x },
+	}
+	for orientation, stored := range at {
+		got := applyOrientation(src, orientation)
+		bounds := got.Bounds()
+		for y := 0; y < bounds.Dy(); y++ {
+			for x := 0; x < bounds.Dx(); x++ {
+				sx, sy := stored(x, y)
+				if got.At(x, y) != src.At(sx, sy) {
+					t.Errorf("orientation %d: expected (%d, %d) to show stored pixel (%d, %d)", orientation, x, y, sx, sy)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+)
+
+const exifOrientationTag = 0x0112
+
+// jpegOrientation reads the EXIF orientation (1-8) from a JPEG, returning 1
+// when there is none or the metadata cannot be parsed.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		// Metadata segments all come before the start of scan.
+		if marker == 0xDA {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// tiffOrientation looks for the orientation tag in the first IFD of an EXIF
+// TIFF block.
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for e := 0; e < entries; e++ {
+		entry := ifd + 2 + e*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) != exifOrientationTag {
+			continue
+		}
+		orientation := int(order.Uint16(tiff[entry+8:]))
+		if orientation < 1 || orientation > 8 {
+			return 1
+		}
+		return orientation
+	}
+	return 1
+}
+
+// applyOrientation returns img as it should be displayed for the given EXIF
+// orientation.
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	dstW, dstH := w, h
+	if orientation >= 5 {
+		dstW, dstH = h, w
+	}
+
+	// Walk the stored rows and scatter each pixel to where it shows up, so
+	// the source is read through rowReader one row at a time.
+	src := newRowReader(img)
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for sy := 0; sy < h; sy++ {
+		row := src.row(bounds.Min.Y + sy)
+		for sx := 0; sx < w; sx++ {
+			// (x, y) is where the stored pixel (sx, sy) shows up.
+			var x, y int
+			switch orientation {
+			case 2: // mirrored
+				x, y = w-1-sx, sy
+			case 3: // rotated 180
+				x, y = w-1-sx, h-1-sy
+			case 4: // mirrored vertically
+				x, y = sx, h-1-sy
+			case 5: // transposed
+				x, y = sy, sx
+			case 6: // needs a 90 degree clockwise turn
+				x, y = h-1-sy, sx
+			case 7: // transversed
+				x, y = h-1-sy, w-1-sx
+			case 8: // needs a 90 degree counter-clockwise turn
+				x, y = sy, w-1-sx
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):], row[4*sx:4*sx+4])
+		}
+	}
+	return dst
+}
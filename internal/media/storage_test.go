@@ -0,0 +1,53 @@
+package media
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStorage(t.TempDir(), "/media/")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := store.Put(ctx, "abc.jpg", []byte("image")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	f, err := store.Open(ctx, "abc.jpg")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil || string(data) != "image" {
+		t.Errorf("Expected stored bytes back, got %q, %v", data, err)
+	}
+	if url := store.URL("abc.jpg"); url != "/media/abc.jpg" {
+		t.Errorf("Expected /media/abc.jpg, got %s", url)
+	}
+
+	if err := store.Delete(ctx, "abc.jpg"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := store.Open(ctx, "abc.jpg"); err != ErrNotFound {
+		t.Errorf("Expected %v, got %v", ErrNotFound, err)
+	}
+	if err := store.Delete(ctx, "abc.jpg"); err != nil {
+		t.Errorf("Expected deleting a missing key to succeed, got %v", err)
+	}
+}
+
+func TestLocalStorage_InvalidKeys(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir(), "/media/")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, key := range []string{"", "../etc/passwd", "a/b.jpg", `a\b.jpg`, ".upload-1"} {
+		if err := store.Put(context.Background(), key, nil); err != ErrInvalidKey {
+			t.Errorf("Expected %v for key %q, got %v", ErrInvalidKey, key, err)
+		}
+	}
+}
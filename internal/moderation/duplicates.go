@@ -0,0 +1,54 @@
+package moderation
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecentChirpsFunc returns the chirps an author posted since a time. Only ID,
+// ParentID and Body need to be filled in.
+type RecentChirpsFunc func(ctx context.Context, authorID uuid.UUID, since time.Time) ([]Chirp, error)
+
+// DuplicateFilter rejects a chirp whose body repeats one the author posted
+// within the window. Case and spacing are ignored, so "Hello  world" repeats
+// "hello world". Replies only repeat replies to the same chirp, so a short
+// "Thanks!" can go in any number of threads.
+type DuplicateFilter struct {
+	window time.Duration
+	recent RecentChirpsFunc
+	now    func() time.Time
+}
+
+func NewDuplicateFilter(window time.Duration, recent RecentChirpsFunc) *DuplicateFilter {
+	return &DuplicateFilter{window: window, recent: recent, now: time.Now}
+}
+
+func (f *DuplicateFilter) Moderate(ctx context.Context, chirp Chirp) (Decision, error) {
+	body := normalizeBody(chirp.Body)
+	// Chirps that are only attachments or a poll have no body to repeat.
+	if body == "" {
+		return Decision{Action: Allow}, nil
+	}
+	// Chirp timestamps are stored without a zone, in UTC.
+	recent, err := f.recent(ctx, chirp.AuthorID, f.now().UTC().Add(-f.window))
+	if err != nil {
+		return Decision{}, err
+	}
+	for _, posted := range recent {
+		// An edit can keep the body it already has.
+		if posted.ID == chirp.ID && chirp.ID != uuid.Nil {
+			continue
+		}
+		if posted.ParentID == chirp.ParentID && normalizeBody(posted.Body) == body {
+			return Decision{Action: Reject, Reason: "You already posted this chirp"}, nil
+		}
+	}
+	return Decision{Action: Allow}, nil
+}
+
+func normalizeBody(body string) string {
+	return strings.ToLower(strings.Join(strings.Fields(body), " "))
+}
@@ -0,0 +1,51 @@
+package moderation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestDuplicateFilter(t *testing.T) {
+	// The clock is read in a zone other than UTC, as a server's may be.
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.FixedZone("UTC+2", 2*60*60))
+	authorID, postedID, replyID := uuid.New(), uuid.New(), uuid.New()
+	thread := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	otherThread := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	var since time.Time
+	f := NewDuplicateFilter(time.Hour, func(ctx context.Context, id uuid.UUID, s time.Time) ([]Chirp, error) {
+		if id != authorID {
+			t.Errorf("expected recent chirps of %v, got %v", authorID, id)
+		}
+		since = s
+		return []Chirp{{ID: postedID, Body: "Hello  world"}, {ID: replyID, ParentID: thread, Body: "Thanks!"}}, nil
+	})
+	f.now = func() time.Time { return now }
+
+	tests := map[string]struct {
+		chirp Chirp
+		want  Action
+	}{
+		"repeat":                 {Chirp{AuthorID: authorID, Body: "hello world"}, Reject},
+		"spacing":                {Chirp{AuthorID: authorID, Body: " HELLO\nworld "}, Reject},
+		"different":              {Chirp{AuthorID: authorID, Body: "hello there"}, Allow},
+		"empty body":             {Chirp{AuthorID: authorID}, Allow},
+		"editing itself":         {Chirp{ID: postedID, AuthorID: authorID, Body: "hello world"}, Allow},
+		"same thread":            {Chirp{AuthorID: authorID, ParentID: thread, Body: "thanks!"}, Reject},
+		"other thread":           {Chirp{AuthorID: authorID, ParentID: otherThread, Body: "thanks!"}, Allow},
+		"reply repeats original": {Chirp{AuthorID: authorID, ParentID: thread, Body: "hello world"}, Allow},
+		"original repeats reply": {Chirp{AuthorID: authorID, Body: "thanks!"}, Allow},
+	}
+	for name, tt := range tests {
+		decision, err := f.Moderate(context.Background(), tt.chirp)
+		if err != nil || decision.Action != tt.want {
+			t.Errorf("%s: expected %v, got %v, %v", name, tt.want, decision.Action, err)
+		}
+	}
+	want := now.UTC().Add(-time.Hour)
+	if !since.Equal(want) || since.Location() != time.UTC {
+		t.Errorf("expected chirps since %v, got %v", want, since)
+	}
+}
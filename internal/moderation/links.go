@@ -0,0 +1,66 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Chirpy/internal/entities"
+)
+
+// LinkBlocklist rejects chirps that link to a blocked domain or any of its
+// subdomains.
+type LinkBlocklist struct {
+	domains map[string]bool
+}
+
+// NewLinkBlocklist blocks domains, given as host names such as
+// "example.com". Blank entries are ignored.
+func NewLinkBlocklist(domains []string) *LinkBlocklist {
+	b := &LinkBlocklist{domains: make(map[string]bool, len(domains))}
+	for _, domain := range domains {
+		if domain = normalizeHost(domain); domain != "" {
+			b.domains[domain] = true
+		}
+	}
+	return b
+}
+
+func (b *LinkBlocklist) Moderate(ctx context.Context, chirp Chirp) (Decision, error) {
+	texts := append([]string{chirp.Body, chirp.ContentWarning}, chirp.PollOptions...)
+	for _, text := range texts {
+		for _, link := range entities.Parse(text).URLs {
+			if domain, ok := b.blocked(link.URL); ok {
+				return Decision{Action: Reject, Reason: fmt.Sprintf("Links to %s are not allowed", domain)}, nil
+			}
+		}
+	}
+	return Decision{Action: Allow}, nil
+}
+
+// blocked returns the blocked domain rawURL points into, if any.
+func (b *LinkBlocklist) blocked(rawURL string) (string, bool) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	host := normalizeHost(parsed.Hostname())
+	for host != "" {
+		if b.domains[host] {
+			return host, true
+		}
+		_, parent, found := strings.Cut(host, ".")
+		if !found {
+			break
+		}
+		host = parent
+	}
+	return "", false
+}
+
+// normalizeHost lower-cases a host name and drops the trailing dot of a
+// fully qualified name, so "Example.COM." and "example.com" are the same.
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
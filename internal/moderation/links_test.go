@@ -0,0 +1,30 @@
+package moderation
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLinkBlocklist(t *testing.T) {
+	b := NewLinkBlocklist([]string{"Spam.example.", " ", "bad.test"})
+	tests := map[string]struct {
+		chirp Chirp
+		want  Action
+	}{
+		"no links":         {Chirp{Body: "hello spam.example"}, Allow},
+		"allowed link":     {Chirp{Body: "see https://example.com/spam"}, Allow},
+		"blocked":          {Chirp{Body: "see https://spam.example/offer"}, Reject},
+		"upper case":       {Chirp{Body: "see HTTP://SPAM.EXAMPLE"}, Reject},
+		"subdomain":        {Chirp{Body: "see https://www.spam.example/"}, Reject},
+		"lookalike suffix": {Chirp{Body: "see https://notspam.example/"}, Allow},
+		"with port":        {Chirp{Body: "see https://bad.test:8080/x"}, Reject},
+		"content warning":  {Chirp{ContentWarning: "https://bad.test"}, Reject},
+		"poll option":      {Chirp{PollOptions: []string{"yes", "https://bad.test"}}, Reject},
+	}
+	for name, tt := range tests {
+		decision, err := b.Moderate(context.Background(), tt.chirp)
+		if err != nil || decision.Action != tt.want {
+			t.Errorf("%s: expected %v, got %v, %v", name, tt.want, decision.Action, err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Package moderation runs chirps through an ordered chain of moderators
+// before they are saved. Each moderator can let a chirp through, change it,
+// hold it for review or reject it.
+package moderation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Action is what a moderator decided to do with a chirp.
+type Action int
+
+const (
+	// Allow lets the chirp through unchanged.
+	Allow Action = iota
+	// Transform lets the chirp through as Decision.Chirp.
+	Transform
+	// Hold lets the chirp through for review. Until a reviewer approves it,
+	// only its author can see it.
+	Hold
+	// Reject refuses the chirp; the rest of the chain does not run.
+	Reject
+)
+
+func (a Action) String() string {
+	switch a {
+	case Allow:
+		return "allow"
+	case Transform:
+		return "transform"
+	case Hold:
+		return "hold"
+	case Reject:
+		return "reject"
+	}
+	return fmt.Sprintf("Action(%d)", int(a))
+}
+
+// Chirp is the text of a chirp as moderators see it. Moderators must not
+// change it in place; they return a changed copy with Transform instead.
+type Chirp struct {
+	// ID is set when an existing chirp is being edited, and zero for a new
+	// chirp.
+	ID       uuid.UUID
+	AuthorID uuid.UUID
+	// ParentID is the chirp a reply answers, and null for anything else.
+	ParentID       uuid.NullUUID
+	Body           string
+	ContentWarning string
+	PollOptions    []string
+}
+
+// Decision is a moderator's verdict on a chirp. Reason is shown to the author
+// on Reject and to reviewers on Hold.
+type Decision struct {
+	Action Action
+	Reason string
+	// Chirp replaces the chirp for the rest of the chain. Transform must set
+	// it; Hold may, to hold a changed chirp.
+	Chirp *Chirp
+}
+
+// Moderator checks one rule. An error means the rule could not be checked,
+// not that the chirp broke it.
+type Moderator interface {
+	Moderate(ctx context.Context, chirp Chirp) (Decision, error)
+}
+
+// ModeratorFunc lets an ordinary function act as a Moderator.
+type ModeratorFunc func(ctx context.Context, chirp Chirp) (Decision, error)
+
+func (f ModeratorFunc) Moderate(ctx context.Context, chirp Chirp) (Decision, error) {
+	return f(ctx, chirp)
+}
+
+// RejectedError is returned by Pipeline.Run when a moderator rejects a chirp.
+type RejectedError struct {
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return e.Reason
+}
+
+// Result is a chirp that made it through a Pipeline.
+type Result struct {
+	Chirp Chirp
+	// Held is set when any moderator held the chirp, and HoldReasons lists
+	// why, in chain order.
+	Held        bool
+	HoldReasons []string
+}
+
+// Pipeline runs moderators in the order they were given. Each one sees the
+// chirp as changed by the ones before it.
+type Pipeline struct {
+	moderators []Moderator
+}
+
+func NewPipeline(moderators ...Moderator) *Pipeline {
+	return &Pipeline{moderators: moderators}
+}
+
+// Run passes chirp through every moderator. It stops at the first rejection,
+// returning a *RejectedError, or at the first moderator that fails.
+func (p *Pipeline) Run(ctx context.Context, chirp Chirp) (Result, error) {
+	result := Result{Chirp: chirp}
+	for _, m := range p.moderators {
+		decision, err := m.Moderate(ctx, result.Chirp)
+		if err != nil {
+			return Result{}, err
+		}
+		switch decision.Action {
+		case Allow:
+		case Transform, Hold:
+			if decision.Chirp != nil {
+				result.Chirp = *decision.Chirp
+			} else if decision.Action == Transform {
+				return Result{}, fmt.Errorf("moderation: %T transformed without a chirp", m)
+			}
+			if decision.Action == Hold {
+				result.Held = true
+				result.HoldReasons = append(result.HoldReasons, decision.Reason)
+			}
+		case Reject:
+			return Result{}, &RejectedError{Reason: decision.Reason}
+		default:
+			return Result{}, fmt.Errorf("moderation: %T returned %v", m, decision.Action)
+		}
+	}
+	return result, nil
+}
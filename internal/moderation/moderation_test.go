@@ -0,0 +1,83 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func upperBody(ctx context.Context, chirp Chirp) (Decision, error) {
+	chirp.Body = strings.ToUpper(chirp.Body)
+	return Decision{Action: Transform, Chirp: &chirp}, nil
+}
+
+func hold(reason string) ModeratorFunc {
+	return func(ctx context.Context, chirp Chirp) (Decision, error) {
+		return Decision{Action: Hold, Reason: reason}, nil
+	}
+}
+
+func TestPipelineRun(t *testing.T) {
+	var seen string
+	record := ModeratorFunc(func(ctx context.Context, chirp Chirp) (Decision, error) {
+		seen = chirp.Body
+		return Decision{Action: Allow}, nil
+	})
+	p := NewPipeline(ModeratorFunc(upperBody), hold("links"), record, hold("new account"))
+
+	result, err := p.Run(context.Background(), Chirp{Body: "hello"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if seen != "HELLO" {
+		t.Errorf("expected later moderators to see the transformed body, got %q", seen)
+	}
+	want := Result{Chirp: Chirp{Body: "HELLO"}, Held: true, HoldReasons: []string{"links", "new account"}}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("expected %+v, got %+v", want, result)
+	}
+}
+
+func TestPipelineRejectStops(t *testing.T) {
+	reached := false
+	p := NewPipeline(
+		hold("first"),
+		ModeratorFunc(func(ctx context.Context, chirp Chirp) (Decision, error) {
+			return Decision{Action: Reject, Reason: "No thanks"}, nil
+		}),
+		ModeratorFunc(func(ctx context.Context, chirp Chirp) (Decision, error) {
+			reached = true
+			return Decision{Action: Allow}, nil
+		}),
+	)
+
+	_, err := p.Run(context.Background(), Chirp{Body: "hello"})
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) || rejected.Reason != "No thanks" {
+		t.Fatalf("expected a rejection, got %v", err)
+	}
+	if reached {
+		t.Errorf("expected the chain to stop at the rejection")
+	}
+}
+
+func TestPipelineErrors(t *testing.T) {
+	failure := errors.New("lookup failed")
+	for name, m := range map[string]ModeratorFunc{
+		"moderator error": func(ctx context.Context, chirp Chirp) (Decision, error) {
+			return Decision{}, failure
+		},
+		"transform without chirp": func(ctx context.Context, chirp Chirp) (Decision, error) {
+			return Decision{Action: Transform}, nil
+		},
+		"unknown action": func(ctx context.Context, chirp Chirp) (Decision, error) {
+			return Decision{Action: Action(42)}, nil
+		},
+	} {
+		if _, err := NewPipeline(m).Run(context.Background(), Chirp{}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Cursor marks the last row of a page in a keyset ordered by (created_at, id).
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// Encode returns the opaque string handed to clients as next_cursor.
+func (c Cursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, errors.New("invalid cursor")
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 2 {
+		return Cursor{}, errors.New("invalid cursor")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return Cursor{}, errors.New("invalid cursor")
+	}
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return Cursor{}, errors.New("invalid cursor")
+	}
+	return Cursor{CreatedAt: createdAt, ID: id}, nil
+}
+
+// ParseLimit reads a page size from a query parameter, falling back to
+// DefaultLimit when it is empty.
+func ParseLimit(s string) (int, error) {
+	if s == "" {
+		return DefaultLimit, nil
+	}
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit < 1 || limit > MaxLimit {
+		return 0, errors.New("limit must be between 1 and " + strconv.Itoa(MaxLimit))
+	}
+	return limit, nil
+}
@@ -0,0 +1,54 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := Cursor{
+		CreatedAt: time.Date(2025, 8, 1, 12, 30, 45, 123456000, time.UTC),
+		ID:        uuid.New(),
+	}
+
+	decoded, err := DecodeCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !decoded.CreatedAt.Equal(cursor.CreatedAt) {
+		t.Errorf("Expected created_at %v, got %v", cursor.CreatedAt, decoded.CreatedAt)
+	}
+	if decoded.ID != cursor.ID {
+		t.Errorf("Expected id %v, got %v", cursor.ID, decoded.ID)
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	inputs := []string{"", "not-base64!", "bm8tc2VwYXJhdG9y", "YWJjfGRlZg"}
+	for _, input := range inputs {
+		if _, err := DecodeCursor(input); err == nil {
+			t.Errorf("Expected error for cursor %q", input)
+		}
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("")
+	if err != nil || limit != DefaultLimit {
+		t.Errorf("Expected default limit %d, got %d (%v)", DefaultLimit, limit, err)
+	}
+
+	limit, err = ParseLimit("5")
+	if err != nil || limit != 5 {
+		t.Errorf("Expected limit 5, got %d (%v)", limit, err)
+	}
+
+	for _, input := range []string{"0", "-1", "abc", "101"} {
+		if _, err := ParseLimit(input); err == nil {
+			t.Errorf("Expected error for limit %q", input)
+		}
+	}
+}
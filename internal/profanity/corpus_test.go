@@ -0,0 +1,94 @@
+package profanity
+
+import (
+	"bufio"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+)
+
+// readCleanWords loads ordinary words, including ones that contain a banned
+// word ("assessment", "Scunthorpe"), that must never be masked.
+func readCleanWords(t testing.TB) []string {
+	t.Helper()
+	f, err := os.Open("testdata/clean_words.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if word := strings.TrimSpace(scanner.Text()); word != "" {
+			words = append(words, word)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return words
+}
+
+// TestCorpusNeverMasked builds sentences from the clean corpus with random
+// casing and punctuation and checks nothing in them is masked.
+func TestCorpusNeverMasked(t *testing.T) {
+	m := NewMatcher(testWords)
+	words := readCleanWords(t)
+	rng := rand.New(rand.NewSource(1))
+	prefixes := []string{"", "", "", "@", "#", "(", `"`}
+	suffixes := []string{"", "", "", ".", ",", "!", "?", "'s", ")", `"`, "...", ":"}
+
+	for _, word := range words {
+		if matches := m.Find(word); len(matches) != 0 {
+			t.Errorf("%q: expected no matches, got %v", word, matches)
+		}
+	}
+
+	for n := 0; n < 2000; n++ {
+		var b strings.Builder
+		for i := 0; i < 3+rng.Intn(10); i++ {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			word := words[rng.Intn(len(words))]
+			switch rng.Intn(4) {
+			case 0:
+				word = strings.ToUpper(word)
+			case 1:
+				word = strings.ToUpper(word[:1]) + word[1:]
+			}
+			b.WriteString(prefixes[rng.Intn(len(prefixes))])
+			b.WriteString(word)
+			b.WriteString(suffixes[rng.Intn(len(suffixes))])
+		}
+		text := b.String()
+		if got := Mask(text, m.Find(text)); got != text {
+			t.Fatalf("%q: expected no masking, got %q", text, got)
+		}
+	}
+}
+
+// FuzzMask checks that matching never panics, that matches come in order
+// without overlapping, and that text without matches is left as it is.
+func FuzzMask(f *testing.F) {
+	for _, seed := range []string{"what a kerfuffle", "k.e.r.f.u.f.f.l.e", "ker\u200bfuffle", "@$$", "assessment", ""} {
+		f.Add(seed)
+	}
+	m := NewMatcher(testWords)
+	f.Fuzz(func(t *testing.T, text string) {
+		matches := m.Find(text)
+		masked := Mask(text, matches)
+		if len(matches) == 0 && masked != text {
+			t.Fatalf("%q: masked without matches to %q", text, masked)
+		}
+		last := 0
+		for _, match := range matches {
+			if match.Start < last || match.End <= match.Start || match.End > len(text) {
+				t.Fatalf("%q: bad match %v", text, match)
+			}
+			last = match.End
+		}
+	})
+}
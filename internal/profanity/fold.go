@@ -0,0 +1,144 @@
+package profanity
+
+import "unicode"
+
+// baseLetters maps accented Latin letters and look-alikes from other scripts
+// to the ASCII letter a reader would take them for. Keys are lower case;
+// runes are lowered before the lookup.
+var baseLetters = map[rune]rune{}
+
+func init() {
+	for base, variants := range map[rune]string{
+		'a': "àáâãäåāăąǎǟǡǻȁȃȧаαɑａ",
+		'b': "ƀɓвβｂ",
+		'c': "çćĉċčƈсϲｃ",
+		'd': "ďđɗｄ",
+		'e': "èéêëēĕėęěȅȇȩеεёɛｅ",
+		'f': "ƒｆ",
+		'g': "ĝğġģǧǵｇ",
+		'h': "ĥħнｈ",
+		'i': "ìíîïĩīĭįıǐȉȋіιїｉ",
+		'j': "ĵǰјｊ",
+		'k': "ķĸǩкκｋ",
+		'l': "ĺļľŀłｌ",
+		'm': "мｍ",
+		'n': "ñńņňŉŋǹηпｎ",
+		'o': "òóôõöøōŏőơǒǫǿȍȏȯоοσｏ",
+		'p': "рρｐ",
+		'q': "ｑ",
+		'r': "ŕŗřȑȓгｒ",
+		's': "śŝşšșѕſｓ",
+		't': "ţťŧțтτｔ",
+		'u': "ùúûüũūŭůűųưǔǖǘǚǜȕȗυｕ",
+		'v': "νѵｖ",
+		'w': "ŵωшｗ",
+		'x': "хχｘ",
+		'y': "ýÿŷуγｙ",
+		'z': "źżžƶｚ",
+	} {
+		for _, r := range variants {
+			baseLetters[r] = base
+		}
+	}
+}
+
+// leetLetters lists the letters a digit or symbol is commonly typed in place
+// of. Some stand for more than one letter.
+var leetLetters = map[rune]string{
+	'0': "o",
+	'1': "il",
+	'3': "e",
+	'4': "a",
+	'5': "s",
+	'7': "t",
+	'8': "b",
+	'9': "g",
+	'@': "a",
+	'$': "s",
+	'!': "i",
+	'|': "il",
+	'+': "t",
+	'€': "e",
+}
+
+type unitKind int
+
+const (
+	// kindSpace separates words and is never part of a match.
+	kindSpace unitKind = iota
+	// kindLetter is a letter or digit; it must be matched, never skipped.
+	kindLetter
+	// kindSymbol is punctuation. It ends a word, but inside a match it can
+	// stand in for a letter or be skipped as padding ("k.e.r").
+	kindSymbol
+	// kindApostrophe ends a word like a symbol but is never skipped, so
+	// "as's" does not read as "ass".
+	kindApostrophe
+)
+
+// unit is one visible character of the text with the letters it can be read
+// as. Invisible runes such as combining marks and zero-width spaces are
+// folded into the unit before them.
+type unit struct {
+	start, end int
+	kind       unitKind
+	letters    string
+}
+
+func (u unit) canBe(letter rune) bool {
+	for _, l := range u.letters {
+		if l == letter {
+			return true
+		}
+	}
+	return false
+}
+
+// isInvisible reports runes that change nothing a reader sees at this
+// position, so obfuscators can slip them into words.
+func isInvisible(r rune) bool {
+	switch r {
+	case '\u00ad', '\u200b', '\u200c', '\u200d', '\u2060', '\ufeff':
+		return true
+	}
+	return unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r)
+}
+
+// foldLetter lowers r and maps look-alikes to their base letter.
+func foldLetter(r rune) rune {
+	r = unicode.ToLower(r)
+	if base, ok := baseLetters[r]; ok {
+		return base
+	}
+	return r
+}
+
+// toUnits breaks text into units.
+func toUnits(text string) []unit {
+	units := make([]unit, 0, len(text))
+	for i, r := range text {
+		end := i + len(string(r))
+		if isInvisible(r) && len(units) > 0 && units[len(units)-1].kind != kindSpace {
+			units[len(units)-1].end = end
+			continue
+		}
+		u := unit{start: i, end: end}
+		switch {
+		case unicode.IsSpace(r):
+			u.kind = kindSpace
+		case unicode.IsLetter(r):
+			u.kind = kindLetter
+			u.letters = string(foldLetter(r))
+		case unicode.IsDigit(r):
+			u.kind = kindLetter
+			u.letters = leetLetters[r]
+		case r == '\'' || r == '’':
+			u.kind = kindApostrophe
+		default:
+			u.kind = kindSymbol
+			u.letters = leetLetters[foldLetter(r)]
+		}
+		units = append(units, u)
+	}
+	return units
+}
@@ -0,0 +1,164 @@
+// Package profanity finds banned words in text written to get past a
+// filter: odd casing, punctuation, look-alike characters, leetspeak and
+// stretched letters. Matches always cover whole words, so banned words
+// inside longer, ordinary words are left alone.
+package profanity
+
+import (
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// Match is a banned word found in a text. Start and End are byte offsets,
+// and Word is the index of the word in the list the Matcher was built from.
+type Match struct {
+	Start, End int
+	Word       int
+}
+
+// letterRun is a letter and how many times in a row a banned word uses it.
+type letterRun struct {
+	letter rune
+	count  int
+}
+
+type Matcher struct {
+	words [][]letterRun
+}
+
+// NewMatcher prepares words for matching. Words are folded the same way as
+// the text, so "Kerfuffle" and "kerfuffle" are the same word; words that
+// fold to nothing never match.
+func NewMatcher(words []string) *Matcher {
+	m := &Matcher{words: make([][]letterRun, len(words))}
+	for i, word := range words {
+		var runs []letterRun
+		for _, r := range word {
+			if isInvisible(r) {
+				continue
+			}
+			letter := foldLetter(r)
+			if n := len(runs); n > 0 && runs[n-1].letter == letter {
+				runs[n-1].count++
+				continue
+			}
+			runs = append(runs, letterRun{letter: letter, count: 1})
+		}
+		m.words[i] = runs
+	}
+	return m
+}
+
+// Find returns the banned words in text, in order and without overlaps.
+// Where several words match at the same place the longest match wins.
+func (m *Matcher) Find(text string) []Match {
+	units := toUnits(text)
+	var matches []Match
+	for i := 0; i < len(units); i++ {
+		if units[i].kind == kindSpace || (i > 0 && units[i-1].kind == kindLetter) {
+			continue
+		}
+		best, bestEnd := -1, -1
+		for w, runs := range m.words {
+			if len(runs) == 0 {
+				continue
+			}
+			if end := matchWord(units, i, runs); end > bestEnd {
+				best, bestEnd = w, end
+			}
+		}
+		if best >= 0 {
+			matches = append(matches, Match{Start: units[i].start, End: units[bestEnd-1].end, Word: best})
+			i = bestEnd - 1
+		}
+	}
+	return matches
+}
+
+// matchWord tries to read runs starting at units[start]. It returns the
+// index just past the last unit of the longest match that ends a word, or
+// -1.
+func matchWord(units []unit, start int, runs []letterRun) int {
+	type state struct {
+		i, r, c int
+		padded  bool
+	}
+	memo := make(map[state]int)
+
+	// match continues with units[i] after c letters of runs[r]. padded is
+	// set when the unit before i was skipped as padding, since a match
+	// cannot end on padding.
+	var match func(i, r, c int, padded bool) int
+	match = func(i, r, c int, padded bool) int {
+		// Stretched letters beyond the word's own count are all alike.
+		c = min(c, runs[r].count)
+		key := state{i, r, c, padded}
+		if end, ok := memo[key]; ok {
+			return end
+		}
+
+		best := -1
+		if i < len(units) && units[i].kind != kindSpace {
+			u := units[i]
+			if u.canBe(runs[r].letter) {
+				best = max(best, match(i+1, r, c+1, false))
+			}
+			if c == runs[r].count && r+1 < len(runs) && u.canBe(runs[r+1].letter) {
+				best = max(best, match(i+1, r+1, 1, false))
+			}
+			if u.kind == kindSymbol {
+				best = max(best, match(i+1, r, c, true))
+			}
+		}
+		if !padded && r == len(runs)-1 && c == runs[r].count && endsWord(units, i) {
+			best = max(best, i)
+		}
+		memo[key] = best
+		return best
+	}
+
+	if !units[start].canBe(runs[0].letter) {
+		return -1
+	}
+	return match(start+1, 0, 1, false)
+}
+
+// endsWord reports whether a word can end just before units[i].
+func endsWord(units []unit, i int) bool {
+	return i == len(units) || units[i].kind != kindLetter
+}
+
+// Mask replaces each match with one asterisk per visible character it
+// covers, so the text keeps its shape.
+func Mask(text string, matches []Match) string {
+	if len(matches) == 0 {
+		return text
+	}
+	var b strings.Builder
+	b.Grow(len(text))
+	last := 0
+	for _, match := range matches {
+		b.WriteString(text[last:match.Start])
+		b.WriteString(strings.Repeat("*", visibleLength(text[match.Start:match.End])))
+		last = match.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// visibleLength counts the user-perceived characters in s, leaving out any
+// made only of invisible runes.
+func visibleLength(s string) int {
+	n := 0
+	graphemes := uniseg.NewGraphemes(s)
+	for graphemes.Next() {
+		for _, r := range graphemes.Runes() {
+			if !isInvisible(r) {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,88 @@
+package profanity
+
+import "testing"
+
+var testWords = []string{"kerfuffle", "sharbert", "fornax", "ass", "hell", "damn", "crap", "butt"}
+
+func TestMaskObfuscated(t *testing.T) {
+	m := NewMatcher(testWords)
+	tests := map[string]struct {
+		text string
+		want string
+	}{
+		"plain":            {"what a kerfuffle", "what a *********"},
+		"upper case":       {"KERFUFFLE", "*********"},
+		"mixed case":       {"KerFuFFle", "*********"},
+		"trailing bang":    {"Kerfuffle!", "*********!"},
+		"quoted":           {`"kerfuffle"`, `"*********"`},
+		"possessive":       {"kerfuffle's", "*********'s"},
+		"comma list":       {"fornax,sharbert", "******,********"},
+		"leet digits":      {"k3rfuffl3", "*********"},
+		"leet symbols":     {"@$$", "***"},
+		"leet one":         {"he11", "****"},
+		"repeated letters": {"kerrrfuuuffle", "*************"},
+		"stretched end":    {"daaaamn", "*******"},
+		"dotted":           {"k.e.r.f.u.f.f.l.e", "*****************"},
+		"dashed":           {"f-o-r-n-a-x!", "***********!"},
+		"underscored":      {"c_r_a_p", "*******"},
+		"cyrillic":         {"kеrfuffle", "*********"},
+		"greek":            {"fοrnαx", "******"},
+		"fullwidth":        {"ｋｅｒｆｕｆｆｌｅ", "*********"},
+		"accented":         {"kérfüffle", "*********"},
+		"combining mark":   {"ke\u0301rfuffle", "*********"},
+		"zero width":       {"ker\u200bfuffle", "*********"},
+		"soft hyphen":      {"sharb\u00adert", "********"},
+		"mention":          {"@kerfuffle", "@*********"},
+		"hashtag":          {"#kerfuffle", "#*********"},
+		"several":          {"hell, what a kerfuffle", "****, what a *********"},
+		"double letter":    {"butt", "****"},
+	}
+	for name, tt := range tests {
+		if got := Mask(tt.text, m.Find(tt.text)); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", name, tt.want, got)
+		}
+	}
+}
+
+func TestFindLeavesWordsAlone(t *testing.T) {
+	m := NewMatcher(testWords)
+	for _, text := range []string{
+		"kerfuffles",
+		"assessment",
+		"class",
+		"passage",
+		"shell",
+		"hello",
+		"damnation",
+		"scrap",
+		"but",
+		"buttress",
+		"has s",
+		"ass2",
+		"kerfufle",
+		"he11o",
+		"as's",
+	} {
+		if matches := m.Find(text); len(matches) != 0 {
+			t.Errorf("%q: expected no matches, got %v", text, matches)
+		}
+	}
+}
+
+func TestFindReportsWordIndex(t *testing.T) {
+	m := NewMatcher([]string{"spam", "scam"})
+	matches := m.Find("spam or sc4m")
+	if len(matches) != 2 || matches[0].Word != 0 || matches[1].Word != 1 {
+		t.Fatalf("expected spam then scam, got %v", matches)
+	}
+	if matches[1].Start != 8 || matches[1].End != 12 {
+		t.Errorf("expected the second match at 8:12, got %d:%d", matches[1].Start, matches[1].End)
+	}
+}
+
+func TestFindIgnoresEmptyWords(t *testing.T) {
+	m := NewMatcher([]string{"", "\u200b"})
+	if matches := m.Find("anything at all"); len(matches) != 0 {
+		t.Errorf("expected no matches, got %v", matches)
+	}
+}
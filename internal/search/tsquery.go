@@ -0,0 +1,52 @@
+package search
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// BuildTSQuery turns a user search string into a to_tsquery expression.
+// Quoted text becomes a phrase match, a trailing * makes a prefix match and
+// every remaining word must be present.
+func BuildTSQuery(q string) (string, error) {
+	var clauses []string
+
+	parts := strings.Split(q, `"`)
+	for i, part := range parts {
+		// Odd segments sit between a pair of quotes. An unmatched trailing
+		// quote is treated as plain words.
+		if i%2 == 1 && i < len(parts)-1 {
+			words := lexemes(part)
+			if len(words) > 0 {
+				clauses = append(clauses, "("+strings.Join(words, " <-> ")+")")
+			}
+			continue
+		}
+		for _, field := range strings.Fields(part) {
+			prefix := strings.HasSuffix(field, "*")
+			words := lexemes(field)
+			if len(words) == 0 {
+				continue
+			}
+			clause := strings.Join(words, " <-> ")
+			if prefix {
+				clause += ":*"
+			}
+			clauses = append(clauses, clause)
+		}
+	}
+
+	if len(clauses) == 0 {
+		return "", errors.New("search query is empty")
+	}
+	return strings.Join(clauses, " & "), nil
+}
+
+// lexemes strips everything but letters and digits so user input can never
+// inject tsquery operators.
+func lexemes(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
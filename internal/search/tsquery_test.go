@@ -0,0 +1,38 @@
+package search
+
+import "testing"
+
+func TestBuildTSQuery(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"hello", "hello"},
+		{"Hello World", "hello & world"},
+		{`"big news" today`, "(big <-> news) & today"},
+		{"chirp*", "chirp:*"},
+		{`go* "fast apis"`, "go:* & (fast <-> apis)"},
+		{"it's", "it <-> s"},
+		{`"unterminated quote`, "unterminated & quote"},
+		{"a & b | !c", "a & b & c"},
+	}
+
+	for _, tt := range tests {
+		got, err := BuildTSQuery(tt.input)
+		if err != nil {
+			t.Errorf("BuildTSQuery(%q) returned error %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("BuildTSQuery(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestBuildTSQuery_Empty(t *testing.T) {
+	for _, input := range []string{"", "   ", `""`, "&|!*"} {
+		if _, err := BuildTSQuery(input); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Package views counts chirp views in memory so they can be written to the
+// database in batches.
+package views
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type viewKey struct {
+	chirpID uuid.UUID
+	viewer  string
+}
+
+// Counter collects views between flushes. A viewer seeing the same chirp
+// again within the dedupe window is not counted twice.
+type Counter struct {
+	mu      sync.Mutex
+	window  time.Duration
+	now     func() time.Time
+	pending map[uuid.UUID]int64
+	seen    map[viewKey]time.Time
+}
+
+func NewCounter(window time.Duration) *Counter {
+	return &Counter{
+		window:  window,
+		now:     time.Now,
+		pending: make(map[uuid.UUID]int64),
+		seen:    make(map[viewKey]time.Time),
+	}
+}
+
+// Record counts a view of a chirp by viewer, an opaque string identifying
+// who is looking. It reports whether the view was counted.
+func (c *Counter) Record(chirpID uuid.UUID, viewer string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := viewKey{chirpID: chirpID, viewer: viewer}
+	now := c.now()
+	if last, ok := c.seen[key]; ok && now.Sub(last) < c.window {
+		return false
+	}
+	c.seen[key] = now
+	c.pending[chirpID]++
+	return true
+}
+
+// Flush hands back the views counted since the last flush and forgets
+// viewers whose dedupe window has passed.
+func (c *Counter) Flush() map[uuid.UUID]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for key, last := range c.seen {
+		if now.Sub(last) >= c.window {
+			delete(c.seen, key)
+		}
+	}
+	pending := c.pending
+	c.pending = make(map[uuid.UUID]int64)
+	return pending
+}
+
+// Restore puts back counts from a flush that could not be written, so they
+// go out with the next one.
+func (c *Counter) Restore(counts map[uuid.UUID]int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for chirpID, n := range counts {
+		c.pending[chirpID] += n
+	}
+}
@@ -0,0 +1,64 @@
+package views
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCounterDedupe(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := NewCounter(30 * time.Minute)
+	c.now = func() time.Time { return now }
+
+	chirpA, chirpB := uuid.New(), uuid.New()
+	if !c.Record(chirpA, "alice") {
+		t.Errorf("first view was not counted")
+	}
+	if c.Record(chirpA, "alice") {
+		t.Errorf("repeat view within the window was counted")
+	}
+	c.Record(chirpA, "bob")
+	c.Record(chirpB, "alice")
+
+	now = now.Add(30 * time.Minute)
+	if !c.Record(chirpA, "alice") {
+		t.Errorf("view after the window was not counted")
+	}
+
+	got := c.Flush()
+	if got[chirpA] != 3 || got[chirpB] != 1 || len(got) != 2 {
+		t.Errorf("expected 3 views of A and 1 of B, got %v", got)
+	}
+	if got := c.Flush(); len(got) != 0 {
+		t.Errorf("expected an empty second flush, got %v", got)
+	}
+}
+
+func TestCounterFlushForgetsExpiredViewers(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := NewCounter(time.Minute)
+	c.now = func() time.Time { return now }
+
+	chirpID := uuid.New()
+	c.Record(chirpID, "alice")
+	now = now.Add(time.Minute)
+	c.Flush()
+	if len(c.seen) != 0 {
+		t.Errorf("expected expired viewers to be forgotten, %d left", len(c.seen))
+	}
+}
+
+func TestCounterRestore(t *testing.T) {
+	c := NewCounter(time.Minute)
+	chirpID := uuid.New()
+	c.Record(chirpID, "alice")
+
+	failed := c.Flush()
+	c.Record(chirpID, "bob")
+	c.Restore(failed)
+	if got := c.Flush(); got[chirpID] != 2 {
+		t.Errorf("expected restored and new views to add up to 2, got %d", got[chirpID])
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+type chirpLike struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt string    `json:"created_at"`
+}
+
+type chirpLikePage struct {
+	Likes      []chirpLike `json:"likes"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+func (cfg *apiConfig) handleLikeChirp(w http.ResponseWriter, r *http.Request) {
+	cfg.setChirpLike(w, r, true)
+}
+
+func (cfg *apiConfig) handleUnlikeChirp(w http.ResponseWriter, r *http.Request) {
+	cfg.setChirpLike(w, r, false)
+}
+
+// setChirpLike adds or removes the caller's like. The like row and the
+// chirp's like_count change in one transaction, and the counter only moves
+// when a row was actually inserted or deleted, so concurrent or repeated
+// requests cannot drift the count.
+func (cfg *apiConfig) setChirpLike(w http.ResponseWriter, r *http.Request, like bool) {
+	w.Header().Set("Content-Type", "application/json")
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userUuid, err := cfg.activeUser(r)
+	if err != nil {
+		respondWithActiveUserError(w, err)
+		return
+	}
+
+	viewer := uuid.NullUUID{UUID: userUuid, Valid: true}
+	_, err = cfg.dbQueries.GetChirpById(r.Context(), database.GetChirpByIdParams{ID: parsedChirpID, ViewerID: viewer})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	likeParams := database.CreateLikeParams{ChirpID: parsedChirpID, UserID: userUuid}
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		if like {
+			inserted, err := q.CreateLike(r.Context(), likeParams)
+			if err != nil || inserted == 0 {
+				return err
+			}
+			return q.IncrementLikeCount(r.Context(), parsedChirpID)
+		}
+		deleted, err := q.DeleteLike(r.Context(), database.DeleteLikeParams(likeParams))
+		if err != nil || deleted == 0 {
+			return err
+		}
+		return q.DecrementLikeCount(r.Context(), parsedChirpID)
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	updatedChirp, err := cfg.dbQueries.GetChirpById(r.Context(), database.GetChirpByIdParams{ID: parsedChirpID, ViewerID: viewer})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	returnChirp := chirpFromDB(updatedChirp)
+	err = cfg.hydrateChirps(r.Context(), viewer, []*chirp{&returnChirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, returnChirp)
+}
+
+func (cfg *apiConfig) handleGetChirpLikes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	query := r.URL.Query()
+
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	listParams := database.ListChirpLikesParams{ChirpID: parsedChirpID, RowLimit: int32(limit + 1)}
+	cursorParam := query.Get("cursor")
+	if cursorParam != "" {
+		cursor, err := pagination.DecodeCursor(cursorParam)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		listParams.AfterCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		listParams.AfterUserID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	viewer, err := cfg.optionalUser(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	_, err = cfg.dbQueries.GetChirpById(r.Context(), database.GetChirpByIdParams{ID: parsedChirpID, ViewerID: viewer})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	likes, err := cfg.dbQueries.ListChirpLikes(r.Context(), listParams)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	page := chirpLikePage{Likes: make([]chirpLike, 0, len(likes))}
+	if len(likes) > limit {
+		likes = likes[:limit]
+		last := likes[limit-1]
+		page.NextCursor = pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.UserID}.Encode()
+	}
+	for _, v := range likes {
+		page.Likes = append(page.Likes, chirpLike{UserID: v.UserID, CreatedAt: v.CreatedAt.String()})
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
@@ -1,11 +1,19 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"log"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/Chirpy/internal/database"
+	"github.com/Chirpy/internal/entities"
+	"github.com/Chirpy/internal/media"
+	"github.com/Chirpy/internal/moderation"
+	"github.com/Chirpy/internal/views"
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
@@ -16,15 +24,47 @@ type user struct {
 	CreatedAt   string    `json:"created_at"`
 	UpdatedAt   string    `json:"updated_at"`
 	Email       string    `json:"email"`
+	Username    string    `json:"username"`
 	IsChirpyRed bool      `json:"is_chirpy_red"`
+	Role        string    `json:"role"`
 }
 
 type chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt string    `json:"created_at"`
-	UpdatedAt string    `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID             uuid.UUID         `json:"id"`
+	CreatedAt      string            `json:"created_at"`
+	UpdatedAt      string            `json:"updated_at"`
+	Body           string            `json:"body"`
+	UserID         uuid.UUID         `json:"user_id"`
+	Entities       entities.Entities `json:"entities"`
+	ParentChirpID  *uuid.UUID        `json:"parent_chirp_id"`
+	RootChirpID    *uuid.UUID        `json:"root_chirp_id"`
+	ReplyCount     int32             `json:"reply_count"`
+	RechirpOfID    *uuid.UUID        `json:"rechirp_of_id,omitempty"`
+	RechirpOf      *chirp            `json:"rechirp_of,omitempty"`
+	QuoteOfID      *uuid.UUID        `json:"quote_of_id,omitempty"`
+	QuotedChirp    *chirp            `json:"quoted_chirp,omitempty"`
+	RechirpCount   int32             `json:"rechirp_count"`
+	QuoteCount     int32             `json:"quote_count"`
+	LikeCount      int32             `json:"like_count"`
+	ViewCount      int64             `json:"view_count"`
+	LikedByMe      bool              `json:"liked_by_me"`
+	BookmarkedByMe bool              `json:"bookmarked_by_me"`
+	EditedAt       string            `json:"edited_at,omitempty"`
+	Deleted        bool              `json:"deleted,omitempty"`
+	PublishAt      string            `json:"publish_at,omitempty"`
+	Media          []mediaAttachment `json:"media,omitempty"`
+	Poll           *poll             `json:"poll,omitempty"`
+	Visibility     string            `json:"visibility"`
+	Pinned         bool              `json:"pinned,omitempty"`
+	ContentWarning string            `json:"content_warning,omitempty"`
+	Sensitive      bool              `json:"sensitive"`
+	Collapsed      bool              `json:"collapsed,omitempty"`
+	FlagReason     string            `json:"flag_reason,omitempty"`
+}
+
+type chirpPage struct {
+	Chirps     []chirp `json:"chirps"`
+	NextCursor string  `json:"next_cursor,omitempty"`
 }
 
 type userResponse struct {
@@ -32,9 +72,11 @@ type userResponse struct {
 	CreatedAt    string    `json:"created_at"`
 	UpdatedAt    string    `json:"updated_at"`
 	Email        string    `json:"email"`
+	Username     string    `json:"username"`
 	Token        string    `json:"token"`
 	RefreshToken string    `json:"refresh_token"`
 	IsChirpyRed  bool      `json:"is_chirpy_red"`
+	Role         string    `json:"role"`
 }
 
 func main() {
@@ -44,6 +86,31 @@ func main() {
 	platform := os.Getenv("PLATFORM")
 	svrToken := os.Getenv("SVR_SECRET")
 	polkaKey := os.Getenv("POLKA_KEY")
+	adminKey := os.Getenv("ADMIN_KEY")
+	chirpEditWindow := defaultChirpEditWindow
+	if editWindow := os.Getenv("CHIRP_EDIT_WINDOW"); editWindow != "" {
+		chirpEditWindow, err = time.ParseDuration(editWindow)
+		if err != nil {
+			log.Fatalf("invalid CHIRP_EDIT_WINDOW: %v", err)
+		}
+	}
+
+	chirpDeleteGrace := defaultChirpDeleteGrace
+	if deleteGrace := os.Getenv("CHIRP_DELETE_GRACE"); deleteGrace != "" {
+		chirpDeleteGrace, err = time.ParseDuration(deleteGrace)
+		if err != nil {
+			log.Fatalf("invalid CHIRP_DELETE_GRACE: %v", err)
+		}
+	}
+
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = defaultMediaDir
+	}
+	mediaStore, err := media.NewLocalStorage(mediaDir, mediaURLPathPrefix)
+	if err != nil {
+		log.Fatalf("opening MEDIA_DIR: %v", err)
+	}
 
 	ServeMux := http.NewServeMux()
 	Server := http.Server{
@@ -52,20 +119,91 @@ func main() {
 	}
 	fs := http.FileServer(http.Dir("."))
 	cfg := &apiConfig{
+		db:        db,
 		dbQueries: database.New(db),
 		platform:  platform,
 		svrToken:  svrToken,
-		apiToken:  polkaKey}
+		apiToken:  polkaKey,
+		adminKey:  adminKey,
+
+		chirpEditWindow:  chirpEditWindow,
+		chirpDeleteGrace: chirpDeleteGrace,
+
+		mediaStore: mediaStore,
+		views:      views.NewCounter(viewDedupeWindow),
+	}
+	// Every new, edited or quoting chirp goes through these in order. Custom
+	// rules are added to the chain here.
+	cfg.moderators = moderation.NewPipeline(
+		moderation.ModeratorFunc(cfg.moderateBannedWords),
+		moderation.NewLinkBlocklist(strings.Split(os.Getenv("BLOCKED_LINK_DOMAINS"), ",")),
+		moderation.NewDuplicateFilter(duplicateChirpWindow, cfg.recentChirps),
+	)
+	if len(os.Args) > 1 {
+		if err := cfg.runCommand(context.Background(), os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	go cfg.runChirpPurger(context.Background(), chirpPurgeInterval)
+	go cfg.runViewFlusher(context.Background(), viewFlushInterval)
 	ServeMux.Handle("/app/", cfg.middlewareMetricsInc(http.StripPrefix("/app", fs)))
 	ServeMux.HandleFunc("GET /admin/metrics", cfg.handlerMetrics)
 	ServeMux.HandleFunc("GET /api/healthz", handleHealthz)
 	ServeMux.HandleFunc("POST /admin/reset", cfg.handlerReset)
+	ServeMux.HandleFunc("PUT /admin/chirps/{chirpID}/sensitive", cfg.handleSetChirpSensitive)
+	ServeMux.HandleFunc("GET /admin/chirps/flagged", cfg.handleGetFlaggedChirps)
+	ServeMux.HandleFunc("POST /admin/chirps/{chirpID}/review", cfg.handleReviewFlaggedChirp)
+	ServeMux.HandleFunc("GET /admin/moderation/reports", cfg.handleGetReports)
+	ServeMux.HandleFunc("POST /admin/moderation/reports/{reportID}/claim", cfg.handleClaimReport)
+	ServeMux.HandleFunc("POST /admin/moderation/reports/{reportID}/resolve", cfg.handleResolveReport)
+	ServeMux.HandleFunc("GET /admin/moderation/log", cfg.handleGetModerationLog)
+	ServeMux.HandleFunc("PUT /admin/users/{userID}/role", cfg.handleSetUserRole)
+	ServeMux.HandleFunc("GET /admin/banned-words", cfg.handleGetBannedWords)
+	ServeMux.HandleFunc("POST /admin/banned-words", cfg.handleCreateBannedWord)
+	ServeMux.HandleFunc("PUT /admin/banned-words/{wordID}", cfg.handleUpdateBannedWord)
+	ServeMux.HandleFunc("DELETE /admin/banned-words/{wordID}", cfg.handleDeleteBannedWord)
 	ServeMux.HandleFunc("POST /api/users", cfg.handleUsers)
 	ServeMux.HandleFunc("PUT /api/users", cfg.handleUserUpdate)
+	ServeMux.HandleFunc("POST /api/users/{userID}/follow", cfg.handleFollowUser)
+	ServeMux.HandleFunc("DELETE /api/users/{userID}/follow", cfg.handleUnfollowUser)
+	ServeMux.HandleFunc("GET /api/users/me/bookmarks", cfg.handleGetBookmarks)
+	ServeMux.HandleFunc("GET /api/users/me/preferences", cfg.handleGetPreferences)
+	ServeMux.HandleFunc("PUT /api/users/me/preferences", cfg.handleUpdatePreferences)
 	ServeMux.HandleFunc("POST /api/chirps", cfg.handleChirps)
+	ServeMux.HandleFunc("GET /api/chirps/search", cfg.handleSearchChirps)
+	ServeMux.HandleFunc("GET /api/chirps/scheduled", cfg.handleGetScheduledChirps)
+	ServeMux.HandleFunc("PUT /api/chirps/{chirpID}/schedule", cfg.handleRescheduleChirp)
+	ServeMux.HandleFunc("DELETE /api/chirps/{chirpID}/schedule", cfg.handleCancelScheduledChirp)
 	ServeMux.HandleFunc("GET /api/chirps/{chirpID}", cfg.handleGetChirpByID)
+	ServeMux.HandleFunc("PUT /api/chirps/{chirpID}", cfg.handleChirpUpdate)
 	ServeMux.HandleFunc("DELETE /api/chirps/{chirpID}", cfg.handleChirpDelete)
+	ServeMux.HandleFunc("GET /api/chirps/{chirpID}/history", cfg.handleGetChirpHistory)
+	ServeMux.HandleFunc("POST /api/chirps/{chirpID}/restore", cfg.handleChirpRestore)
+	ServeMux.HandleFunc("GET /api/chirps/{chirpID}/thread", cfg.handleGetThread)
+	ServeMux.HandleFunc("POST /api/chirps/{chirpID}/rechirp", cfg.handleRechirp)
+	ServeMux.HandleFunc("DELETE /api/chirps/{chirpID}/rechirp", cfg.handleUndoRechirp)
+	ServeMux.HandleFunc("POST /api/chirps/{chirpID}/quote", cfg.handleQuoteChirp)
+	ServeMux.HandleFunc("POST /api/chirps/{chirpID}/like", cfg.handleLikeChirp)
+	ServeMux.HandleFunc("DELETE /api/chirps/{chirpID}/like", cfg.handleUnlikeChirp)
+	ServeMux.HandleFunc("GET /api/chirps/{chirpID}/likes", cfg.handleGetChirpLikes)
+	ServeMux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", cfg.handleBookmarkChirp)
+	ServeMux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", cfg.handleUnbookmarkChirp)
+	ServeMux.HandleFunc("POST /api/chirps/{chirpID}/report", cfg.handleReportChirp)
+	ServeMux.HandleFunc("PUT /api/chirps/{chirpID}/pin", cfg.handlePinChirp)
+	ServeMux.HandleFunc("DELETE /api/chirps/{chirpID}/pin", cfg.handleUnpinChirp)
+	ServeMux.HandleFunc("POST /api/chirps/{chirpID}/poll/votes", cfg.handlePollVote)
 	ServeMux.HandleFunc("GET /api/chirps", cfg.handleGetChirps)
+	ServeMux.HandleFunc("POST /api/media", cfg.handleUploadMedia)
+	ServeMux.HandleFunc("GET "+mediaURLPathPrefix+"{key}", cfg.handleGetMediaFile)
+	ServeMux.HandleFunc("POST /api/drafts", cfg.handleCreateDraft)
+	ServeMux.HandleFunc("GET /api/drafts", cfg.handleGetDrafts)
+	ServeMux.HandleFunc("GET /api/drafts/{draftID}", cfg.handleGetDraft)
+	ServeMux.HandleFunc("PUT /api/drafts/{draftID}", cfg.handleUpdateDraft)
+	ServeMux.HandleFunc("DELETE /api/drafts/{draftID}", cfg.handleDeleteDraft)
+	ServeMux.HandleFunc("POST /api/drafts/{draftID}/publish", cfg.handlePublishDraft)
+	ServeMux.HandleFunc("GET /api/hashtags/trending", cfg.handleTrendingHashtags)
+	ServeMux.HandleFunc("GET /api/hashtags/{tag}/chirps", cfg.handleGetHashtagChirps)
 	ServeMux.HandleFunc("POST /api/login", cfg.handleLogin)
 	ServeMux.HandleFunc("POST /api/refresh", cfg.handleRefresh)
 	ServeMux.HandleFunc("POST /api/revoke", cfg.handleRevoke)
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/Chirpy/internal/media"
+	"github.com/google/uuid"
+)
+
+const (
+	maxChirpMedia      = 4
+	maxMediaAltText    = 1000
+	mediaOrphanMaxAge  = 24 * time.Hour
+	multipartOverhead  = 64 << 10
+	defaultMediaDir    = "media"
+	mediaURLPathPrefix = "/media/"
+)
+
+type mediaAttachment struct {
+	ID           uuid.UUID `json:"id"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	ContentType  string    `json:"content_type"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+	AltText      string    `json:"alt_text"`
+}
+
+func (cfg *apiConfig) mediaAttachmentFromDB(m database.Medium) mediaAttachment {
+	return mediaAttachment{
+		ID:           m.ID,
+		URL:          cfg.mediaStore.URL(m.StorageKey),
+		ThumbnailURL: cfg.mediaStore.URL(m.ThumbnailKey),
+		ContentType:  m.ContentType,
+		Width:        m.Width,
+		Height:       m.Height,
+		AltText:      m.AltText,
+	}
+}
+
+// handleUploadMedia takes a multipart "file" plus optional "alt_text". The
+// upload stays unattached until a chirp lists its id in media_ids.
+func (cfg *apiConfig) handleUploadMedia(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userUuid, err := cfg.activeUser(r)
+	if err != nil {
+		respondWithActiveUserError(w, err)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, media.MaxUploadBytes+multipartOverhead)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "File is too large")
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Missing file")
+		return
+	}
+	defer file.Close()
+	if header.Size > media.MaxUploadBytes {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "File is too large")
+		return
+	}
+	altText := r.FormValue("alt_text")
+	if utf8.RuneCountInString(altText) > maxMediaAltText {
+		respondWithError(w, http.StatusBadRequest, "Alt text is too long")
+		return
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Something went wrong")
+		return
+	}
+	processed, err := media.Process(data)
+	switch err {
+	case nil:
+	case media.ErrUnsupportedType:
+		respondWithError(w, http.StatusUnsupportedMediaType, "Only JPEG and PNG images are supported")
+		return
+	case media.ErrInvalidImage, media.ErrImageTooLarge:
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	default:
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	mediaID := uuid.New()
+	storageKey := mediaID.String() + processed.Ext
+	thumbnailKey := mediaID.String() + "_thumb" + processed.Ext
+	if err := cfg.mediaStore.Put(r.Context(), storageKey, processed.Data); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if err := cfg.mediaStore.Put(r.Context(), thumbnailKey, processed.Thumbnail); err != nil {
+		cfg.deleteMediaFiles(r.Context(), storageKey)
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	uploaded, err := cfg.dbQueries.CreateMedia(r.Context(), database.CreateMediaParams{
+		ID:           mediaID,
+		UserID:       userUuid,
+		ContentType:  processed.ContentType,
+		StorageKey:   storageKey,
+		ThumbnailKey: thumbnailKey,
+		Width:        int32(processed.Width),
+		Height:       int32(processed.Height),
+		AltText:      altText,
+	})
+	if err != nil {
+		cfg.deleteMediaFiles(r.Context(), storageKey, thumbnailKey)
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, cfg.mediaAttachmentFromDB(uploaded))
+}
+
+// handleGetMediaFile serves stored files for backends that do not have their
+// own public URLs.
+func (cfg *apiConfig) handleGetMediaFile(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	file, err := cfg.mediaStore.Open(r.Context(), key)
+	if err != nil {
+		if err == media.ErrNotFound || err == media.ErrInvalidKey {
+			http.NotFound(w, r)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", mime.TypeByExtension(filepath.Ext(key)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// Keys are never reused, so clients can keep files indefinitely.
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	_, _ = io.Copy(w, file)
+}
+
+// attachChirpMedia fills in the attachments of chirps that are still visible.
+func (cfg *apiConfig) attachChirpMedia(ctx context.Context, chirps []*chirp) error {
+	chirpIDs := make([]uuid.UUID, 0, len(chirps))
+	for _, c := range chirps {
+		if !c.Deleted {
+			chirpIDs = append(chirpIDs, c.ID)
+		}
+	}
+	if len(chirpIDs) == 0 {
+		return nil
+	}
+
+	attachments, err := cfg.dbQueries.ListMediaForChirps(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+	byChirp := make(map[uuid.UUID][]mediaAttachment)
+	for _, m := range attachments {
+		byChirp[m.ChirpID.UUID] = append(byChirp[m.ChirpID.UUID], cfg.mediaAttachmentFromDB(m))
+	}
+	for _, c := range chirps {
+		if !c.Deleted {
+			c.Media = byChirp[c.ID]
+		}
+	}
+	return nil
+}
+
+// purgeOrphanedMedia removes uploads that never made it onto a chirp, or
+// whose chirp has since been purged.
+func (cfg *apiConfig) purgeOrphanedMedia(ctx context.Context) error {
+	orphaned, err := cfg.dbQueries.DeleteOrphanedMedia(ctx, time.Now().UTC().Add(-mediaOrphanMaxAge))
+	if err != nil {
+		return err
+	}
+	for _, m := range orphaned {
+		cfg.deleteMediaFiles(ctx, m.StorageKey, m.ThumbnailKey)
+	}
+	return nil
+}
+
+// deleteMediaFiles removes stored files on a best-effort basis; a file left
+// behind only costs disk space.
+func (cfg *apiConfig) deleteMediaFiles(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := cfg.mediaStore.Delete(ctx, key); err != nil {
+			log.Printf("deleting media file %s: %v", key, err)
+		}
+	}
+}
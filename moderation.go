@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/Chirpy/internal/moderation"
+	"github.com/google/uuid"
+)
+
+// duplicateChirpWindow is how far back the duplicate filter looks for a chirp
+// with the same body.
+const duplicateChirpWindow = 24 * time.Hour
+
+// recentChirps feeds the duplicate filter from the author's own chirps.
+func (cfg *apiConfig) recentChirps(ctx context.Context, authorID uuid.UUID, since time.Time) ([]moderation.Chirp, error) {
+	rows, err := cfg.dbQueries.ListRecentChirpBodies(ctx, database.ListRecentChirpBodiesParams{
+		UserID:    authorID,
+		CreatedAt: since,
+	})
+	if err != nil {
+		return nil, err
+	}
+	recent := make([]moderation.Chirp, 0, len(rows))
+	for _, row := range rows {
+		recent = append(recent, moderation.Chirp{ID: row.ID, AuthorID: authorID, ParentID: row.ParentChirpID, Body: row.Body})
+	}
+	return recent, nil
+}
+
+// flagHeldChirp sends a chirp the moderation chain held to the review list,
+// keeping every moderator's reason.
+func flagHeldChirp(ctx context.Context, q *database.Queries, chirpID uuid.UUID, result moderation.Result) error {
+	if !result.Held {
+		return nil
+	}
+	return q.FlagChirp(ctx, database.FlagChirpParams{
+		ID:         chirpID,
+		FlagReason: strings.Join(result.HoldReasons, "; "),
+	})
+}
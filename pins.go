@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// handlePinChirp pins one of the caller's chirps to their profile, replacing
+// any chirp pinned before.
+func (cfg *apiConfig) handlePinChirp(w http.ResponseWriter, r *http.Request) {
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userUuid, err := cfg.activeUser(r)
+	if err != nil {
+		respondWithActiveUserError(w, err)
+		return
+	}
+	if _, err := cfg.ownedChirp(r.Context(), parsedChirpID, userUuid); err != nil {
+		respondWithOwnedChirpError(w, err)
+		return
+	}
+
+	err = cfg.dbQueries.PinChirp(r.Context(), database.PinChirpParams{
+		ID:            userUuid,
+		PinnedChirpID: uuid.NullUUID{UUID: parsedChirpID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleUnpinChirp clears the caller's pin if it points at this chirp, so an
+// unpin racing a newer pin cannot remove the newer one.
+func (cfg *apiConfig) handleUnpinChirp(w http.ResponseWriter, r *http.Request) {
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userUuid, err := cfg.activeUser(r)
+	if err != nil {
+		respondWithActiveUserError(w, err)
+		return
+	}
+
+	_, err = cfg.dbQueries.UnpinChirp(r.Context(), database.UnpinChirpParams{
+		ID:            userUuid,
+		PinnedChirpID: uuid.NullUUID{UUID: parsedChirpID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// addPinnedChirp puts an author's pinned chirp on their timeline. It leads
+// the first page and is left out of every page after that, so paging through
+// the whole timeline lists it exactly once.
+func (cfg *apiConfig) addPinnedChirp(ctx context.Context, viewer uuid.NullUUID, authorID uuid.UUID, firstPage bool, page *chirpPage) error {
+	pinned, err := cfg.dbQueries.GetPinnedChirp(ctx, database.GetPinnedChirpParams{
+		UserID:   authorID,
+		ViewerID: viewer,
+	})
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !firstPage {
+		page.Chirps = placePinnedChirp(page.Chirps, pinned.ID, nil)
+		return nil
+	}
+	pinnedChirp := chirpFromDB(pinned)
+	if err := cfg.hydrateChirps(ctx, viewer, []*chirp{&pinnedChirp}); err != nil {
+		return err
+	}
+	page.Chirps = placePinnedChirp(page.Chirps, pinned.ID, &pinnedChirp)
+	return nil
+}
+
+// placePinnedChirp removes the pinned chirp from its place in chirps and, when
+// pinned is given, puts it first marked as pinned.
+func placePinnedChirp(chirps []chirp, pinnedID uuid.UUID, pinned *chirp) []chirp {
+	placed := make([]chirp, 0, len(chirps)+1)
+	if pinned != nil {
+		pinned.Pinned = true
+		placed = append(placed, *pinned)
+	}
+	for _, c := range chirps {
+		if c.ID != pinnedID {
+			placed = append(placed, c)
+		}
+	}
+	return placed
+}
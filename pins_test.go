@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPlacePinnedChirp(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	page := []chirp{{ID: a}, {ID: b}, {ID: c}}
+
+	ids := func(chirps []chirp) []uuid.UUID {
+		out := make([]uuid.UUID, len(chirps))
+		for i, v := range chirps {
+			out[i] = v.ID
+		}
+		return out
+	}
+
+	got := placePinnedChirp(page, b, &chirp{ID: b})
+	if want := []uuid.UUID{b, a, c}; !reflect.DeepEqual(ids(got), want) {
+		t.Errorf("pinned chirp on page: expected %v, got %v", want, ids(got))
+	}
+	if !got[0].Pinned || got[1].Pinned {
+		t.Errorf("expected only the first chirp to be marked pinned")
+	}
+
+	pinned := uuid.New()
+	got = placePinnedChirp(page, pinned, &chirp{ID: pinned})
+	if want := []uuid.UUID{pinned, a, b, c}; !reflect.DeepEqual(ids(got), want) {
+		t.Errorf("pinned chirp off page: expected %v, got %v", want, ids(got))
+	}
+
+	got = placePinnedChirp(page, c, nil)
+	if want := []uuid.UUID{a, b}; !reflect.DeepEqual(ids(got), want) {
+		t.Errorf("later page: expected %v, got %v", want, ids(got))
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 4
+	maxPollOptionLength = 25
+	minPollDuration     = 5 * time.Minute
+	maxPollDuration     = 7 * 24 * time.Hour
+)
+
+var (
+	errPollOptionCount     = fmt.Errorf("A poll needs %d to %d options", minPollOptions, maxPollOptions)
+	errPollOptionLength    = fmt.Errorf("Poll options must be 1 to %d characters", maxPollOptionLength)
+	errPollOptionDuplicate = errors.New("Poll options must be different")
+	errPollDuration        = errors.New("A poll must close between 5 minutes and 7 days after it is posted")
+	errPollClosed          = errors.New("Poll is closed")
+	errPollAlreadyVoted    = errors.New("Already voted")
+)
+
+// pollInput is the poll part of a new chirp request.
+type pollInput struct {
+	Options  []string  `json:"options"`
+	ClosesAt time.Time `json:"closes_at"`
+}
+
+// preparedPoll is a validated poll waiting to be written with its chirp.
+type preparedPoll struct {
+	labels   []string
+	closesAt time.Time
+}
+
+type pollOption struct {
+	ID    uuid.UUID `json:"id"`
+	Label string    `json:"label"`
+	Votes *int32    `json:"votes,omitempty"`
+}
+
+// poll is how a poll appears on a chirp. Tallies are left out until the
+// viewer has voted or the poll has closed.
+type poll struct {
+	ClosesAt      string       `json:"closes_at"`
+	Closed        bool         `json:"closed"`
+	Options       []pollOption `json:"options"`
+	TotalVotes    *int32       `json:"total_votes,omitempty"`
+	VotedOptionID *uuid.UUID   `json:"voted_option_id,omitempty"`
+}
+
+// preparePoll checks a poll against the time its chirp goes live, which is
+// later than now for scheduled chirps.
+func preparePoll(input *pollInput, publishAt sql.NullTime, now time.Time) (*preparedPoll, error) {
+	if input == nil {
+		return nil, nil
+	}
+	if len(input.Options) < minPollOptions || len(input.Options) > maxPollOptions {
+		return nil, errPollOptionCount
+	}
+	labels := make([]string, 0, len(input.Options))
+	seen := make(map[string]bool, len(input.Options))
+	for _, option := range input.Options {
+		label := strings.TrimSpace(option)
+		if label == "" || utf8.RuneCountInString(label) > maxPollOptionLength {
+			return nil, errPollOptionLength
+		}
+		if seen[strings.ToLower(label)] {
+			return nil, errPollOptionDuplicate
+		}
+		seen[strings.ToLower(label)] = true
+		labels = append(labels, label)
+	}
+
+	opensAt := now
+	if publishAt.Valid {
+		opensAt = publishAt.Time
+	}
+	duration := input.ClosesAt.Sub(opensAt)
+	if duration < minPollDuration || duration > maxPollDuration {
+		return nil, errPollDuration
+	}
+	return &preparedPoll{labels: labels, closesAt: input.ClosesAt.UTC()}, nil
+}
+
+// insertPoll writes a prepared poll for a chirp created in the same
+// transaction.
+func insertPoll(ctx context.Context, q *database.Queries, chirpID uuid.UUID, prepared *preparedPoll) error {
+	err := q.CreatePoll(ctx, database.CreatePollParams{ChirpID: chirpID, ClosesAt: prepared.closesAt})
+	if err != nil {
+		return err
+	}
+	return q.CreatePollOptions(ctx, database.CreatePollOptionsParams{ChirpID: chirpID, Labels: prepared.labels})
+}
+
+func (cfg *apiConfig) handlePollVote(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		OptionID uuid.UUID `json:"option_id"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userUuid, err := cfg.activeUser(r)
+	if err != nil {
+		respondWithActiveUserError(w, err)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Something went wrong")
+		return
+	}
+
+	// Polls on chirps the caller cannot see do not exist as far as they know.
+	viewer := uuid.NullUUID{UUID: userUuid, Valid: true}
+	if _, err := cfg.dbQueries.GetChirpById(r.Context(), database.GetChirpByIdParams{ID: parsedChirpID, ViewerID: viewer}); err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Poll not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	chirpPoll, err := cfg.dbQueries.GetPoll(r.Context(), parsedChirpID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Poll not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if !time.Now().UTC().Before(chirpPoll.ClosesAt) {
+		respondWithError(w, http.StatusBadRequest, errPollClosed.Error())
+		return
+	}
+	options, err := cfg.dbQueries.ListPollOptionsForChirps(r.Context(), []uuid.UUID{parsedChirpID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	validOption := false
+	for _, option := range options {
+		validOption = validOption || option.ID == params.OptionID
+	}
+	if !validOption {
+		respondWithError(w, http.StatusBadRequest, "Unknown poll option")
+		return
+	}
+
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		inserted, err := q.CreatePollVote(r.Context(), database.CreatePollVoteParams{
+			UserID:   userUuid,
+			OptionID: params.OptionID,
+			ChirpID:  parsedChirpID,
+		})
+		if err != nil {
+			return err
+		}
+		if inserted == 0 {
+			// The option was checked above, so the poll either closed in the
+			// meantime or the user has a vote already.
+			return errPollAlreadyVoted
+		}
+		return q.IncrementPollOptionVoteCount(r.Context(), params.OptionID)
+	})
+	if err != nil {
+		if err == errPollAlreadyVoted {
+			respondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	votedChirp, err := cfg.dbQueries.GetChirpById(r.Context(), database.GetChirpByIdParams{ID: parsedChirpID, ViewerID: viewer})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	returnChirp := chirpFromDB(votedChirp)
+	err = cfg.hydrateChirps(r.Context(), viewer, []*chirp{&returnChirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, returnChirp)
+}
+
+// attachPolls fills in the polls on chirps, revealing tallies to viewers who
+// have voted and to everyone once a poll closes.
+func (cfg *apiConfig) attachPolls(ctx context.Context, viewer uuid.NullUUID, chirps []*chirp) error {
+	chirpIDs := make([]uuid.UUID, 0, len(chirps))
+	for _, c := range chirps {
+		if !c.Deleted {
+			chirpIDs = append(chirpIDs, c.ID)
+		}
+	}
+	if len(chirpIDs) == 0 {
+		return nil
+	}
+	polls, err := cfg.dbQueries.ListPollsForChirps(ctx, chirpIDs)
+	if err != nil || len(polls) == 0 {
+		return err
+	}
+
+	pollIDs := make([]uuid.UUID, len(polls))
+	for i, p := range polls {
+		pollIDs[i] = p.ChirpID
+	}
+	options, err := cfg.dbQueries.ListPollOptionsForChirps(ctx, pollIDs)
+	if err != nil {
+		return err
+	}
+	optionsByChirp := make(map[uuid.UUID][]database.PollOption, len(polls))
+	for _, option := range options {
+		optionsByChirp[option.ChirpID] = append(optionsByChirp[option.ChirpID], option)
+	}
+	votes := make(map[uuid.UUID]uuid.UUID)
+	if viewer.Valid {
+		rows, err := cfg.dbQueries.GetPollVotesByUser(ctx, database.GetPollVotesByUserParams{
+			UserID:   viewer.UUID,
+			ChirpIds: pollIDs,
+		})
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			votes[row.ChirpID] = row.OptionID
+		}
+	}
+
+	now := time.Now().UTC()
+	byChirp := make(map[uuid.UUID]*poll, len(polls))
+	for _, p := range polls {
+		returnPoll := &poll{
+			ClosesAt: p.ClosesAt.String(),
+			Closed:   !now.Before(p.ClosesAt),
+			Options:  []pollOption{},
+		}
+		votedOptionID, voted := votes[p.ChirpID]
+		if voted {
+			returnPoll.VotedOptionID = &votedOptionID
+		}
+		showTallies := voted || returnPoll.Closed
+		var total int32
+		for _, option := range optionsByChirp[p.ChirpID] {
+			returnOption := pollOption{ID: option.ID, Label: option.Label}
+			if showTallies {
+				count := option.VoteCount
+				returnOption.Votes = &count
+				total += count
+			}
+			returnPoll.Options = append(returnPoll.Options, returnOption)
+		}
+		if showTallies {
+			returnPoll.TotalVotes = &total
+		}
+		byChirp[p.ChirpID] = returnPoll
+	}
+	for _, c := range chirps {
+		if !c.Deleted {
+			c.Poll = byChirp[c.ID]
+		}
+	}
+	return nil
+}
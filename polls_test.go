@@ -0,0 +1,57 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPreparePoll(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	prepared, err := preparePoll(nil, sql.NullTime{}, now)
+	if err != nil || prepared != nil {
+		t.Errorf("Expected no poll for nil input, got %v, %v", prepared, err)
+	}
+
+	prepared, err = preparePoll(&pollInput{
+		Options:  []string{" Yes ", "No", "kerfuffle"},
+		ClosesAt: now.Add(24 * time.Hour),
+	}, sql.NullTime{}, now)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := strings.Join(prepared.labels, ","); got != "Yes,No,kerfuffle" {
+		t.Errorf("Expected trimmed labels, got %q", got)
+	}
+	if !prepared.closesAt.Equal(now.Add(24 * time.Hour)) {
+		t.Errorf("Expected closes_at %v, got %v", now.Add(24*time.Hour), prepared.closesAt)
+	}
+}
+
+func TestPreparePoll_Invalid(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	closesAt := now.Add(time.Hour)
+	scheduled := sql.NullTime{Time: now.Add(48 * time.Hour), Valid: true}
+
+	tests := map[string]struct {
+		input     pollInput
+		publishAt sql.NullTime
+		want      error
+	}{
+		"one option":            {pollInput{Options: []string{"a"}, ClosesAt: closesAt}, sql.NullTime{}, errPollOptionCount},
+		"five options":          {pollInput{Options: []string{"a", "b", "c", "d", "e"}, ClosesAt: closesAt}, sql.NullTime{}, errPollOptionCount},
+		"blank option":          {pollInput{Options: []string{"a", "  "}, ClosesAt: closesAt}, sql.NullTime{}, errPollOptionLength},
+		"long option":           {pollInput{Options: []string{"a", strings.Repeat("é", 26)}, ClosesAt: closesAt}, sql.NullTime{}, errPollOptionLength},
+		"duplicate option":      {pollInput{Options: []string{"Yes", "yes"}, ClosesAt: closesAt}, sql.NullTime{}, errPollOptionDuplicate},
+		"closes too soon":       {pollInput{Options: []string{"a", "b"}, ClosesAt: now.Add(time.Minute)}, sql.NullTime{}, errPollDuration},
+		"closes too late":       {pollInput{Options: []string{"a", "b"}, ClosesAt: now.Add(8 * 24 * time.Hour)}, sql.NullTime{}, errPollDuration},
+		"closes before publish": {pollInput{Options: []string{"a", "b"}, ClosesAt: closesAt}, scheduled, errPollDuration},
+	}
+	for name, tt := range tests {
+		if _, err := preparePoll(&tt.input, tt.publishAt, now); err != tt.want {
+			t.Errorf("%s: expected %v, got %v", name, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/Chirpy/internal/moderation"
+	"github.com/Chirpy/internal/profanity"
+)
+
+// What happens to a chirp containing a banned word.
+const (
+	bannedWordMask   = "mask"
+	bannedWordReject = "reject"
+	bannedWordFlag   = "flag"
+)
+
+// bannedWordCacheTTL bounds how stale another process's copy of the list can
+// get; changes made through this process invalidate its copy at once.
+const bannedWordCacheTTL = 5 * time.Minute
+
+// profanityFilter is the banned word list, with each word's action at the
+// index the matcher reports it under.
+type profanityFilter struct {
+	matcher *profanity.Matcher
+	actions []string
+}
+
+func newProfanityFilter(words []database.BannedWord) *profanityFilter {
+	list := make([]string, len(words))
+	actions := make([]string, len(words))
+	for i, w := range words {
+		list[i] = w.Word
+		actions[i] = w.Action
+	}
+	return &profanityFilter{matcher: profanity.NewMatcher(list), actions: actions}
+}
+
+// newCheck starts checking the text of one chirp.
+func (f *profanityFilter) newCheck() *profanityCheck {
+	return &profanityCheck{filter: f}
+}
+
+// profanityCheck runs every piece of text in a chirp through the filter,
+// masking words as it goes and remembering which actions its words asked for.
+type profanityCheck struct {
+	filter   *profanityFilter
+	masked   bool
+	rejected bool
+	flagged  bool
+}
+
+// clean masks every masking word in text, asterisk for character, and
+// notes any rejecting or flagging word. Words are found however they are
+// disguised; see the profanity package.
+func (c *profanityCheck) clean(text string) string {
+	var masked []profanity.Match
+	for _, match := range c.filter.matcher.Find(text) {
+		switch c.filter.actions[match.Word] {
+		case bannedWordMask:
+			c.masked = true
+			masked = append(masked, match)
+		case bannedWordReject:
+			c.rejected = true
+		case bannedWordFlag:
+			c.flagged = true
+		}
+	}
+	return profanity.Mask(text, masked)
+}
+
+// bannedWordCache holds the filter built from banned_words between changes.
+type bannedWordCache struct {
+	mu       sync.Mutex
+	filter   *profanityFilter
+	loadedAt time.Time
+}
+
+// loadProfanityFilter returns the cached filter, loading it when it is
+// missing or older than bannedWordCacheTTL. The lock is held across the load
+// so an invalidation cannot be overwritten by a load that started before it.
+func (cfg *apiConfig) loadProfanityFilter(ctx context.Context) (*profanityFilter, error) {
+	cache := &cfg.bannedWords
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.filter != nil && time.Since(cache.loadedAt) < bannedWordCacheTTL {
+		return cache.filter, nil
+	}
+	words, err := cfg.dbQueries.ListBannedWords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cache.filter = newProfanityFilter(words)
+	cache.loadedAt = time.Now()
+	return cache.filter, nil
+}
+
+func (cfg *apiConfig) invalidateBannedWords() {
+	cfg.bannedWords.mu.Lock()
+	defer cfg.bannedWords.mu.Unlock()
+	cfg.bannedWords.filter = nil
+}
+
+// moderateBannedWords is the built-in moderator for the banned_words list. It
+// masks words in every text of a chirp, then rejects or holds the chirp if
+// any word asked for that.
+func (cfg *apiConfig) moderateBannedWords(ctx context.Context, c moderation.Chirp) (moderation.Decision, error) {
+	filter, err := cfg.loadProfanityFilter(ctx)
+	if err != nil {
+		return moderation.Decision{}, err
+	}
+	check := filter.newCheck()
+	cleaned := c
+	cleaned.Body = check.clean(c.Body)
+	cleaned.ContentWarning = check.clean(c.ContentWarning)
+	if c.PollOptions != nil {
+		cleaned.PollOptions = make([]string, len(c.PollOptions))
+		for i, option := range c.PollOptions {
+			cleaned.PollOptions[i] = check.clean(option)
+		}
+	}
+
+	switch {
+	case check.rejected:
+		return moderation.Decision{Action: moderation.Reject, Reason: "Chirp contains a banned word"}, nil
+	case check.flagged:
+		return moderation.Decision{Action: moderation.Hold, Reason: "Chirp contains a flagged word", Chirp: &cleaned}, nil
+	case check.masked:
+		return moderation.Decision{Action: moderation.Transform, Reason: "Banned words masked", Chirp: &cleaned}, nil
+	}
+	return moderation.Decision{Action: moderation.Allow}, nil
+}
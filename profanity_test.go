@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/Chirpy/internal/moderation"
+)
+
+func TestProfanityCheck(t *testing.T) {
+	filter := newProfanityFilter([]database.BannedWord{
+		{Word: "kerfuffle", Action: bannedWordMask},
+		{Word: "Spam", Action: bannedWordReject},
+		{Word: "scam", Action: bannedWordFlag},
+	})
+	tests := map[string]struct {
+		text         string
+		want         string
+		wantRejected bool
+		wantFlagged  bool
+	}{
+		"clean":          {"hello there", "hello there", false, false},
+		"masked":         {"what a KERFUFFLE", "what a *********", false, false},
+		"rejected":       {"buy spam now", "buy spam now", true, false},
+		"flagged":        {"not a scam", "not a scam", false, true},
+		"all actions":    {"spam scam kerfuffle", "spam scam *********", true, true},
+		"substring kept": {"kerfuffles", "kerfuffles", false, false},
+	}
+	for name, tt := range tests {
+		check := filter.newCheck()
+		got := check.clean(tt.text)
+		if got != tt.want || check.rejected != tt.wantRejected || check.flagged != tt.wantFlagged {
+			t.Errorf("%s: expected %q rejected=%v flagged=%v, got %q rejected=%v flagged=%v",
+				name, tt.want, tt.wantRejected, tt.wantFlagged, got, check.rejected, check.flagged)
+		}
+	}
+}
+
+func TestModerateBannedWords(t *testing.T) {
+	cfg := &apiConfig{}
+	cfg.bannedWords.filter = newProfanityFilter([]database.BannedWord{
+		{Word: "kerfuffle", Action: bannedWordMask},
+		{Word: "spam", Action: bannedWordReject},
+		{Word: "scam", Action: bannedWordFlag},
+	})
+	cfg.bannedWords.loadedAt = time.Now()
+
+	tests := map[string]struct {
+		chirp      moderation.Chirp
+		wantAction moderation.Action
+		wantChirp  *moderation.Chirp
+	}{
+		"clean": {moderation.Chirp{Body: "hello"}, moderation.Allow, nil},
+		"masked everywhere": {
+			moderation.Chirp{Body: "a kerfuffle", ContentWarning: "kerfuffle", PollOptions: []string{"yes", "kerfuffle"}},
+			moderation.Transform,
+			&moderation.Chirp{Body: "a *********", ContentWarning: "*********", PollOptions: []string{"yes", "*********"}},
+		},
+		"rejected from poll": {moderation.Chirp{Body: "hello", PollOptions: []string{"spam", "eggs"}}, moderation.Reject, nil},
+		"held and masked": {
+			moderation.Chirp{Body: "kerfuffle scam"},
+			moderation.Hold,
+			&moderation.Chirp{Body: "********* scam"},
+		},
+	}
+	for name, tt := range tests {
+		decision, err := cfg.moderateBannedWords(context.Background(), tt.chirp)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+		if decision.Action != tt.wantAction || !reflect.DeepEqual(decision.Chirp, tt.wantChirp) {
+			t.Errorf("%s: expected %v %+v, got %v %+v", name, tt.wantAction, tt.wantChirp, decision.Action, decision.Chirp)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/Chirpy/internal/moderation"
+	"github.com/google/uuid"
+)
+
+var errRepostNotPublic = errors.New("Only public chirps can be reposted")
+
+// repostTarget loads the chirp userID is rechirping or quoting. Reposting a
+// plain rechirp reposts the chirp it points at. Restricted chirps cannot be
+// reposted, since that would show them to the reposter's audience.
+func (cfg *apiConfig) repostTarget(ctx context.Context, userID, chirpID uuid.UUID) (database.Chirp, error) {
+	viewer := uuid.NullUUID{UUID: userID, Valid: true}
+	target, err := cfg.dbQueries.GetChirpById(ctx, database.GetChirpByIdParams{ID: chirpID, ViewerID: viewer})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if target.RechirpOfID.Valid {
+		target, err = cfg.dbQueries.GetChirpById(ctx, database.GetChirpByIdParams{ID: target.RechirpOfID.UUID, ViewerID: viewer})
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+	if target.Visibility != visibilityPublic {
+		return database.Chirp{}, errRepostNotPublic
+	}
+	return target, nil
+}
+
+func (cfg *apiConfig) handleRechirp(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userUuid, err := cfg.activeUser(r)
+	if err != nil {
+		respondWithActiveUserError(w, err)
+		return
+	}
+
+	original, err := cfg.repostTarget(r.Context(), userUuid, parsedChirpID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		if err == errRepostNotPublic {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	var rechirp database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		rechirp, err = q.CreateRechirp(r.Context(), database.CreateRechirpParams{
+			UserID:      userUuid,
+			RechirpOfID: uuid.NullUUID{UUID: original.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		return q.IncrementRechirpCount(r.Context(), original.ID)
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "Chirp already rechirped")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	returnChirp := chirpFromDB(rechirp)
+	err = cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userUuid, Valid: true}, []*chirp{&returnChirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, returnChirp)
+}
+
+func (cfg *apiConfig) handleUndoRechirp(w http.ResponseWriter, r *http.Request) {
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userUuid, err := cfg.activeUser(r)
+	if err != nil {
+		respondWithActiveUserError(w, err)
+		return
+	}
+
+	var deleted int64
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		deleted, err = q.DeleteRechirp(r.Context(), database.DeleteRechirpParams{
+			UserID:      userUuid,
+			RechirpOfID: uuid.NullUUID{UUID: parsedChirpID, Valid: true},
+		})
+		if err != nil || deleted == 0 {
+			return err
+		}
+		return q.DecrementRechirpCount(r.Context(), parsedChirpID)
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Rechirp not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	respondWithJSON(w, http.StatusNoContent, "")
+}
+
+func (cfg *apiConfig) handleQuoteChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userUuid, err := cfg.activeUser(r)
+	if err != nil {
+		respondWithActiveUserError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Something went wrong")
+		return
+	}
+	if params.Body == "" {
+		respondWithError(w, http.StatusBadRequest, "Quote body is required")
+		return
+	}
+	limit, err := cfg.chirpLengthLimit(r.Context(), userUuid)
+	if err != nil {
+		respondWithNewChirpError(w, err)
+		return
+	}
+	if err := checkChirpLength(params.Body, limit); err != nil {
+		respondWithNewChirpError(w, err)
+		return
+	}
+	moderated, err := cfg.moderators.Run(r.Context(), moderation.Chirp{AuthorID: userUuid, Body: params.Body})
+	if err != nil {
+		respondWithNewChirpError(w, err)
+		return
+	}
+	params.Body = moderated.Chirp.Body
+
+	original, err := cfg.repostTarget(r.Context(), userUuid, parsedChirpID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		if err == errRepostNotPublic {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	chirpEntities, err := cfg.chirpEntities(r.Context(), params.Body)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	var quote database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		quote, err = q.CreateChirp(r.Context(), database.CreateChirpParams{
+			Body:       params.Body,
+			UserID:     userUuid,
+			QuoteOfID:  uuid.NullUUID{UUID: original.ID, Valid: true},
+			Entities:   chirpEntities,
+			Visibility: visibilityPublic,
+		})
+		if err != nil {
+			return err
+		}
+		if err := saveChirpHashtags(r.Context(), q, quote); err != nil {
+			return err
+		}
+		if err := flagHeldChirp(r.Context(), q, quote.ID, moderated); err != nil {
+			return err
+		}
+		return q.IncrementQuoteCount(r.Context(), original.ID)
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	returnChirp := chirpFromDB(quote)
+	err = cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userUuid, Valid: true}, []*chirp{&returnChirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, returnChirp)
+}
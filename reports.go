@@ -0,0 +1,514 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+const (
+	maxReportDetailsLength = 500
+	// reportClaimTimeout is how long a claim keeps other moderators off a
+	// report before they can take it over.
+	reportClaimTimeout = 30 * time.Minute
+)
+
+// Reasons a user can give for reporting a chirp.
+var reportReasons = []string{"spam", "harassment", "hate", "violence", "sexual", "self_harm", "misinformation", "other"}
+
+const (
+	reportStatusOpen     = "open"
+	reportStatusClaimed  = "claimed"
+	reportStatusResolved = "resolved"
+)
+
+// How a moderator can resolve a report. Each is also the moderation_log
+// action recorded for it.
+const (
+	resolutionDismiss       = "dismiss"
+	resolutionHideChirp     = "hide_chirp"
+	resolutionSuspendAuthor = "suspend_author"
+)
+
+// Other actions recorded in moderation_log.
+const (
+	moderationActionReport       = "report"
+	moderationActionClaim        = "claim"
+	moderationActionDeleteChirp  = "delete_chirp"
+	moderationActionSetRole      = "set_role"
+	moderationActionApproveChirp = "approve_chirp"
+)
+
+var (
+	errReportReason        = fmt.Errorf("reason must be one of %s", strings.Join(reportReasons, ", "))
+	errReportDetailsLength = fmt.Errorf("details can be at most %d characters", maxReportDetailsLength)
+	errReportOwnChirp      = errors.New("You cannot report your own chirp")
+	errReportStatus        = errors.New("status must be open, claimed or resolved")
+	errResolution          = errors.New("resolution must be dismiss, hide_chirp or suspend_author")
+	errReportResolved      = errors.New("Report is already resolved")
+	errReportClaimed       = errors.New("Report is claimed by another moderator")
+	errReportNotClaimed    = errors.New("Claim the report before resolving it")
+)
+
+type report struct {
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  string     `json:"created_at"`
+	UpdatedAt  string     `json:"updated_at"`
+	ChirpID    uuid.UUID  `json:"chirp_id"`
+	ReporterID uuid.UUID  `json:"reporter_id"`
+	Reason     string     `json:"reason"`
+	Details    string     `json:"details,omitempty"`
+	Status     string     `json:"status"`
+	ClaimedBy  *uuid.UUID `json:"claimed_by,omitempty"`
+	ClaimedAt  string     `json:"claimed_at,omitempty"`
+	Resolution string     `json:"resolution,omitempty"`
+	ResolvedAt string     `json:"resolved_at,omitempty"`
+}
+
+type reportPage struct {
+	Reports    []report `json:"reports"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+func reportFromDB(r database.Report) report {
+	response := report{
+		ID:         r.ID,
+		CreatedAt:  r.CreatedAt.String(),
+		UpdatedAt:  r.UpdatedAt.String(),
+		ChirpID:    r.ChirpID,
+		ReporterID: r.ReporterID,
+		Reason:     r.Reason,
+		Details:    r.Details,
+		Status:     r.Status,
+		Resolution: r.Resolution.String,
+	}
+	if r.ClaimedBy.Valid {
+		response.ClaimedBy = &r.ClaimedBy.UUID
+	}
+	if r.ClaimedAt.Valid {
+		response.ClaimedAt = r.ClaimedAt.Time.String()
+	}
+	if r.ResolvedAt.Valid {
+		response.ResolvedAt = r.ResolvedAt.Time.String()
+	}
+	return response
+}
+
+type moderationLogEntry struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt string     `json:"created_at"`
+	ActorID   *uuid.UUID `json:"actor_id,omitempty"`
+	Action    string     `json:"action"`
+	ReportID  *uuid.UUID `json:"report_id,omitempty"`
+	ChirpID   *uuid.UUID `json:"chirp_id,omitempty"`
+	UserID    *uuid.UUID `json:"user_id,omitempty"`
+	Note      string     `json:"note,omitempty"`
+}
+
+type moderationLogPage struct {
+	Entries    []moderationLogEntry `json:"entries"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+func moderationLogEntryFromDB(e database.ModerationLog) moderationLogEntry {
+	optional := func(id uuid.NullUUID) *uuid.UUID {
+		if !id.Valid {
+			return nil
+		}
+		return &id.UUID
+	}
+	return moderationLogEntry{
+		ID:        e.ID,
+		CreatedAt: e.CreatedAt.String(),
+		ActorID:   optional(e.ActorID),
+		Action:    e.Action,
+		ReportID:  optional(e.ReportID),
+		ChirpID:   optional(e.ChirpID),
+		UserID:    optional(e.UserID),
+		Note:      e.Note,
+	}
+}
+
+// moderationActor identifies who is working the moderation queue: a
+// moderator or admin by their token, or the admin key, which belongs to no
+// user, so its claims and actions carry no actor.
+func (cfg *apiConfig) moderationActor(r *http.Request) (uuid.NullUUID, bool) {
+	return cfg.actorWithRole(r, roleModerator)
+}
+
+func (cfg *apiConfig) handleReportChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userUuid, err := cfg.activeUser(r)
+	if err != nil {
+		respondWithActiveUserError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Something went wrong")
+		return
+	}
+	params.Details = strings.TrimSpace(params.Details)
+	switch {
+	case !isReportReason(params.Reason):
+		respondWithError(w, http.StatusBadRequest, errReportReason.Error())
+		return
+	case utf8.RuneCountInString(params.Details) > maxReportDetailsLength:
+		respondWithError(w, http.StatusBadRequest, errReportDetailsLength.Error())
+		return
+	}
+
+	reported, err := cfg.dbQueries.GetChirpById(r.Context(), database.GetChirpByIdParams{
+		ID:       parsedChirpID,
+		ViewerID: uuid.NullUUID{UUID: userUuid, Valid: true},
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if reported.UserID == userUuid {
+		respondWithError(w, http.StatusBadRequest, errReportOwnChirp.Error())
+		return
+	}
+
+	var created database.Report
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		created, err = q.CreateReport(r.Context(), database.CreateReportParams{
+			ChirpID:    reported.ID,
+			ReporterID: userUuid,
+			Reason:     params.Reason,
+			Details:    params.Details,
+		})
+		if err != nil {
+			return err
+		}
+		return q.CreateModerationLogEntry(r.Context(), database.CreateModerationLogEntryParams{
+			ActorID:  uuid.NullUUID{UUID: userUuid, Valid: true},
+			Action:   moderationActionReport,
+			ReportID: uuid.NullUUID{UUID: created.ID, Valid: true},
+			ChirpID:  uuid.NullUUID{UUID: reported.ID, Valid: true},
+			UserID:   uuid.NullUUID{UUID: reported.UserID, Valid: true},
+			Note:     params.Reason,
+		})
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "You already reported this chirp")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, reportFromDB(created))
+}
+
+func isReportReason(reason string) bool {
+	for _, r := range reportReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// handleGetReports pages through the reports with one status, oldest first.
+// The status defaults to open.
+func (cfg *apiConfig) handleGetReports(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	query := r.URL.Query()
+
+	if _, ok := cfg.moderationActor(r); !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	status := query.Get("status")
+	switch status {
+	case "":
+		status = reportStatusOpen
+	case reportStatusOpen, reportStatusClaimed, reportStatusResolved:
+	default:
+		respondWithError(w, http.StatusBadRequest, errReportStatus.Error())
+		return
+	}
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	listParams := database.ListReportsParams{Status: status, RowLimit: int32(limit + 1)}
+	cursorParam := query.Get("cursor")
+	if cursorParam != "" {
+		cursor, err := pagination.DecodeCursor(cursorParam)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		listParams.AfterCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		listParams.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	reports, err := cfg.dbQueries.ListReports(r.Context(), listParams)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	page := reportPage{Reports: make([]report, 0, len(reports))}
+	if len(reports) > limit {
+		reports = reports[:limit]
+		last := reports[limit-1]
+		page.NextCursor = pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	for _, rep := range reports {
+		page.Reports = append(page.Reports, reportFromDB(rep))
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+func (cfg *apiConfig) handleClaimReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	actor, ok := cfg.moderationActor(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	parsedReportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var claimed database.Report
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		claimed, err = q.ClaimReport(r.Context(), database.ClaimReportParams{
+			ModeratorID: actor,
+			ID:          parsedReportID,
+			StaleBefore: time.Now().UTC().Add(-reportClaimTimeout),
+		})
+		if err == sql.ErrNoRows {
+			return reportUnavailable(r.Context(), q, parsedReportID, errReportClaimed)
+		}
+		if err != nil {
+			return err
+		}
+		return q.CreateModerationLogEntry(r.Context(), database.CreateModerationLogEntryParams{
+			ActorID:  actor,
+			Action:   moderationActionClaim,
+			ReportID: uuid.NullUUID{UUID: claimed.ID, Valid: true},
+			ChirpID:  uuid.NullUUID{UUID: claimed.ChirpID, Valid: true},
+		})
+	})
+	if err != nil {
+		respondWithReportError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, reportFromDB(claimed))
+}
+
+func (cfg *apiConfig) handleResolveReport(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Resolution string `json:"resolution"`
+		Note       string `json:"note"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	actor, ok := cfg.moderationActor(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	parsedReportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Something went wrong")
+		return
+	}
+	switch params.Resolution {
+	case resolutionDismiss, resolutionHideChirp, resolutionSuspendAuthor:
+	default:
+		respondWithError(w, http.StatusBadRequest, errResolution.Error())
+		return
+	}
+
+	var resolved database.Report
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		resolved, err = q.ResolveReport(r.Context(), database.ResolveReportParams{
+			Resolution:  params.Resolution,
+			ID:          parsedReportID,
+			ModeratorID: actor,
+		})
+		if err == sql.ErrNoRows {
+			return reportUnavailable(r.Context(), q, parsedReportID, errReportNotClaimed)
+		}
+		if err != nil {
+			return err
+		}
+		return applyResolution(r.Context(), q, actor, resolved, strings.TrimSpace(params.Note))
+	})
+	if err != nil {
+		respondWithReportError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, reportFromDB(resolved))
+}
+
+// applyResolution carries out a resolution and records it. Hiding the chirp
+// or suspending its author settles every other report on the chirp too, and
+// each of those is recorded as well.
+func applyResolution(ctx context.Context, q *database.Queries, actor uuid.NullUUID, resolved database.Report, note string) error {
+	reported, err := q.GetChirpForModeration(ctx, resolved.ChirpID)
+	if err != nil {
+		return err
+	}
+	switch resolved.Resolution.String {
+	case resolutionHideChirp:
+		if err := q.HideChirp(ctx, reported.ID); err != nil {
+			return err
+		}
+		// A chirp the author already deleted has had its counts adjusted.
+		if !reported.DeletedAt.Valid {
+			if err := adjustReferenceCounts(ctx, q, reported, false); err != nil {
+				return err
+			}
+		}
+	case resolutionSuspendAuthor:
+		if err := q.SuspendUser(ctx, reported.UserID); err != nil {
+			return err
+		}
+		if err := q.RevokeUserRefreshTokens(ctx, reported.UserID); err != nil {
+			return err
+		}
+	}
+
+	entry := database.CreateModerationLogEntryParams{
+		ActorID:  actor,
+		Action:   resolved.Resolution.String,
+		ReportID: uuid.NullUUID{UUID: resolved.ID, Valid: true},
+		ChirpID:  uuid.NullUUID{UUID: reported.ID, Valid: true},
+		UserID:   uuid.NullUUID{UUID: reported.UserID, Valid: true},
+		Note:     note,
+	}
+	if err := q.CreateModerationLogEntry(ctx, entry); err != nil {
+		return err
+	}
+	if resolved.Resolution.String == resolutionDismiss {
+		return nil
+	}
+
+	settled, err := q.ResolveChirpReports(ctx, database.ResolveChirpReportsParams{
+		Resolution: resolved.Resolution.String,
+		ChirpID:    reported.ID,
+	})
+	if err != nil {
+		return err
+	}
+	for _, reportID := range settled {
+		entry.ReportID = uuid.NullUUID{UUID: reportID, Valid: true}
+		entry.Note = fmt.Sprintf("Resolved with report %s", resolved.ID)
+		if err := q.CreateModerationLogEntry(ctx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reportUnavailable explains why a claim or resolve matched no report:
+// sql.ErrNoRows when there is no such report, errReportResolved, or
+// otherwise the error the caller expects.
+func reportUnavailable(ctx context.Context, q *database.Queries, reportID uuid.UUID, otherwise error) error {
+	current, err := q.GetReportById(ctx, reportID)
+	if err != nil {
+		return err
+	}
+	if current.Status == reportStatusResolved {
+		return errReportResolved
+	}
+	return otherwise
+}
+
+func respondWithReportError(w http.ResponseWriter, err error) {
+	switch err {
+	case sql.ErrNoRows:
+		respondWithError(w, http.StatusNotFound, "Report not found")
+	case errReportResolved, errReportClaimed, errReportNotClaimed:
+		respondWithError(w, http.StatusConflict, err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+	}
+}
+
+// handleGetModerationLog pages through moderation_log, newest first.
+func (cfg *apiConfig) handleGetModerationLog(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	query := r.URL.Query()
+
+	if _, ok := cfg.moderationActor(r); !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	listParams := database.ListModerationLogParams{RowLimit: int32(limit + 1)}
+	cursorParam := query.Get("cursor")
+	if cursorParam != "" {
+		cursor, err := pagination.DecodeCursor(cursorParam)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		listParams.AfterCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		listParams.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	entries, err := cfg.dbQueries.ListModerationLog(r.Context(), listParams)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	page := moderationLogPage{Entries: make([]moderationLogEntry, 0, len(entries))}
+	if len(entries) > limit {
+		entries = entries[:limit]
+		last := entries[limit-1]
+		page.NextCursor = pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	for _, entry := range entries {
+		page.Entries = append(page.Entries, moderationLogEntryFromDB(entry))
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestReportFromDB(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	open := reportFromDB(database.Report{ID: uuid.New(), Reason: "spam", Status: reportStatusOpen, CreatedAt: now, UpdatedAt: now})
+	if open.ClaimedBy != nil || open.ClaimedAt != "" || open.Resolution != "" || open.ResolvedAt != "" {
+		t.Errorf("expected an open report to leave claim and resolution empty, got %+v", open)
+	}
+
+	moderatorID := uuid.New()
+	resolved := reportFromDB(database.Report{
+		Status:     reportStatusResolved,
+		ClaimedBy:  uuid.NullUUID{UUID: moderatorID, Valid: true},
+		ClaimedAt:  sql.NullTime{Time: now, Valid: true},
+		Resolution: sql.NullString{String: resolutionHideChirp, Valid: true},
+		ResolvedAt: sql.NullTime{Time: now.Add(time.Minute), Valid: true},
+	})
+	if resolved.ClaimedBy == nil || *resolved.ClaimedBy != moderatorID || resolved.Resolution != resolutionHideChirp || resolved.ResolvedAt == "" {
+		t.Errorf("expected the claim and resolution to be filled in, got %+v", resolved)
+	}
+}
+
+func TestIsReportReason(t *testing.T) {
+	for _, reason := range reportReasons {
+		if !isReportReason(reason) {
+			t.Errorf("expected %q to be a report reason", reason)
+		}
+	}
+	for _, reason := range []string{"", "Spam", "boring"} {
+		if isReportReason(reason) {
+			t.Errorf("expected %q to be rejected", reason)
+		}
+	}
+}
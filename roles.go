@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Roles a user can hold, each allowed everything the ones before it are.
+// Moderators work the moderation queue and can delete any chirp; admins also
+// manage the banned word list and other users' roles.
+const (
+	roleUser      = "user"
+	roleModerator = "moderator"
+	roleAdmin     = "admin"
+)
+
+var roleRanks = map[string]int{roleUser: 0, roleModerator: 1, roleAdmin: 2}
+
+var errInvalidRole = errors.New("role must be user, moderator or admin")
+
+// hasRole reports whether role is at least min. An empty or unknown role
+// counts as roleUser.
+func hasRole(role, min string) bool {
+	return roleRanks[role] >= roleRanks[min]
+}
+
+// actorWithRole checks that the caller holds at least role, either on their
+// account or through the ADMIN_KEY, which acts as an admin on behalf of no
+// user. It returns the user to record as the actor. The role is read from the
+// database on every request, so promotions, demotions and suspensions apply
+// at once rather than when the caller's JWT expires.
+func (cfg *apiConfig) actorWithRole(r *http.Request, role string) (uuid.NullUUID, bool) {
+	if cfg.hasAdminKey(r) {
+		return uuid.NullUUID{}, true
+	}
+	account, err := cfg.activeAccount(r)
+	if err != nil || !hasRole(account.Role, role) {
+		return uuid.NullUUID{}, false
+	}
+	return uuid.NullUUID{UUID: account.ID, Valid: true}, true
+}
+
+// deleteChirpAsModerator removes someone else's chirp, whoever it is visible
+// to. It is hidden rather than deleted, so the author cannot restore it. A
+// moderator's own chirps are not theirs to hide and come back as
+// sql.ErrNoRows.
+func deleteChirpAsModerator(ctx context.Context, q *database.Queries, actor uuid.NullUUID, chirpID uuid.UUID) error {
+	target, err := q.GetChirpByIdForUpdate(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+	if actor.Valid && target.UserID == actor.UUID {
+		return sql.ErrNoRows
+	}
+	if err := q.HideChirp(ctx, target.ID); err != nil {
+		return err
+	}
+	if err := adjustReferenceCounts(ctx, q, target, false); err != nil {
+		return err
+	}
+	return q.CreateModerationLogEntry(ctx, database.CreateModerationLogEntryParams{
+		ActorID: actor,
+		Action:  moderationActionDeleteChirp,
+		ChirpID: uuid.NullUUID{UUID: target.ID, Valid: true},
+		UserID:  uuid.NullUUID{UUID: target.UserID, Valid: true},
+	})
+}
+
+// setUserRole changes a user's role and records who did it.
+func setUserRole(ctx context.Context, q *database.Queries, actor uuid.NullUUID, userID uuid.UUID, role string) (database.User, error) {
+	if _, ok := roleRanks[role]; !ok {
+		return database.User{}, errInvalidRole
+	}
+	updated, err := q.SetUserRole(ctx, database.SetUserRoleParams{ID: userID, Role: role})
+	if err != nil {
+		return database.User{}, err
+	}
+	err = q.CreateModerationLogEntry(ctx, database.CreateModerationLogEntryParams{
+		ActorID: actor,
+		Action:  moderationActionSetRole,
+		UserID:  uuid.NullUUID{UUID: userID, Valid: true},
+		Note:    role,
+	})
+	return updated, err
+}
+
+func (cfg *apiConfig) handleSetUserRole(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Role string `json:"role"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	actor, ok := cfg.actorWithRole(r, roleAdmin)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	parsedUserID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Something went wrong")
+		return
+	}
+
+	var updated database.User
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		updated, err = setUserRole(r.Context(), q, actor, parsedUserID, params.Role)
+		return err
+	})
+	switch {
+	case err == errInvalidRole:
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	case err == sql.ErrNoRows:
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	case err != nil:
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, user{
+		ID:          updated.ID,
+		CreatedAt:   updated.CreatedAt.String(),
+		UpdatedAt:   updated.UpdatedAt.String(),
+		Email:       updated.Email,
+		Username:    updated.Username,
+		IsChirpyRed: updated.IsChirpyRed,
+		Role:        updated.Role,
+	})
+}
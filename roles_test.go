@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestHasRole(t *testing.T) {
+	cases := []struct {
+		role, min string
+		want      bool
+	}{
+		{roleUser, roleUser, true},
+		{roleUser, roleModerator, false},
+		{roleModerator, roleModerator, true},
+		{roleModerator, roleAdmin, false},
+		{roleAdmin, roleModerator, true},
+		{"", roleUser, true},
+		{"", roleModerator, false},
+	}
+	for _, c := range cases {
+		if got := hasRole(c.role, c.min); got != c.want {
+			t.Errorf("hasRole(%q, %q) = %v, want %v", c.role, c.min, got, c.want)
+		}
+	}
+}
+
+func TestActorWithRole(t *testing.T) {
+	cfg := &apiConfig{svrToken: "secret", adminKey: "admin-key"}
+	// A valid token goes on to look the account's role up, which needs a
+	// database; see TestActorWithRole_UsesStoredRole for those cases.
+	cases := []struct {
+		name          string
+		authorization string
+		role          string
+		wantOK        bool
+		wantActor     uuid.NullUUID
+	}{
+		{"admin key", "ApiKey admin-key", roleAdmin, true, uuid.NullUUID{}},
+		{"wrong admin key", "ApiKey nope", roleModerator, false, uuid.NullUUID{}},
+		{"invalid token", "Bearer nope", roleModerator, false, uuid.NullUUID{}},
+		{"nothing", "", roleModerator, false, uuid.NullUUID{}},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "/admin/moderation/reports", nil)
+		if c.authorization != "" {
+			r.Header.Set("Authorization", c.authorization)
+		}
+		actor, ok := cfg.actorWithRole(r, c.role)
+		if ok != c.wantOK || actor != c.wantActor {
+			t.Errorf("%s: got (%v, %v), want (%v, %v)", c.name, actor, ok, c.wantActor, c.wantOK)
+		}
+	}
+}
+
+func TestActorWithRole_UsesStoredRole(t *testing.T) {
+	cfg := newTestConfig(t)
+	ctx := context.Background()
+
+	// Tokens issued before a role change carry the old role.
+	promoted, promotedToken := createTestUser(t, cfg, roleUser)
+	demoted, demotedToken := createTestUser(t, cfg, roleModerator)
+	if _, err := cfg.dbQueries.SetUserRole(ctx, database.SetUserRoleParams{ID: promoted.ID, Role: roleModerator}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.dbQueries.SetUserRole(ctx, database.SetUserRoleParams{ID: demoted.ID, Role: roleUser}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest("GET", "/admin/moderation/reports", nil)
+	r.Header.Set("Authorization", "Bearer "+promotedToken)
+	actor, ok := cfg.actorWithRole(r, roleModerator)
+	if !ok || actor != (uuid.NullUUID{UUID: promoted.ID, Valid: true}) {
+		t.Errorf("promoted user: got (%v, %v), want (%v, true)", actor, ok, promoted.ID)
+	}
+
+	r = httptest.NewRequest("GET", "/admin/moderation/reports", nil)
+	r.Header.Set("Authorization", "Bearer "+demotedToken)
+	if _, ok := cfg.actorWithRole(r, roleModerator); ok {
+		t.Error("demoted moderator still passes the moderator check")
+	}
+}
+
+func TestHandleChirpDelete_ModeratorDeletesFollowersOnlyChirp(t *testing.T) {
+	cfg := newTestConfig(t)
+	author, _ := createTestUser(t, cfg, roleUser)
+	_, moderatorToken := createTestUser(t, cfg, roleModerator)
+	_, userToken := createTestUser(t, cfg, roleUser)
+	chirp := createTestChirp(t, cfg, author.ID, "for my followers", visibilityFollowers)
+
+	deleteAs := func(token string) int {
+		r := httptest.NewRequest("DELETE", "/api/chirps/"+chirp.ID.String(), nil)
+		r.SetPathValue("chirpID", chirp.ID.String())
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		cfg.handleChirpDelete(w, r)
+		return w.Code
+	}
+
+	if code := deleteAs(userToken); code != http.StatusNotFound {
+		t.Errorf("user: got status %d, want %d", code, http.StatusNotFound)
+	}
+	if code := deleteAs(moderatorToken); code != http.StatusNoContent {
+		t.Fatalf("moderator: got status %d, want %d", code, http.StatusNoContent)
+	}
+	found, err := cfg.dbQueries.GetChirpForModeration(context.Background(), chirp.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found.HiddenAt.Valid {
+		t.Error("chirp deleted by a moderator was not hidden")
+	}
+}
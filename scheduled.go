@@ -0,0 +1,150 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxScheduleAhead = 365 * 24 * time.Hour
+
+var (
+	errPublishAtPast   = errors.New("publish_at must be in the future")
+	errPublishAtTooFar = errors.New("publish_at must be within a year")
+	errScheduledReply  = errors.New("Replies cannot be scheduled")
+)
+
+// parsePublishAt validates a requested publish time. A nil value means the
+// chirp goes out immediately.
+func parsePublishAt(publishAt *time.Time, now time.Time) (sql.NullTime, error) {
+	if publishAt == nil {
+		return sql.NullTime{}, nil
+	}
+	if !publishAt.After(now) {
+		return sql.NullTime{}, errPublishAtPast
+	}
+	if publishAt.Sub(now) > maxScheduleAhead {
+		return sql.NullTime{}, errPublishAtTooFar
+	}
+	// Timestamps are stored without a zone and read back as UTC.
+	return sql.NullTime{Time: publishAt.UTC(), Valid: true}, nil
+}
+
+// handleGetScheduledChirps lists the caller's chirps that have not been
+// published yet, soonest first.
+func (cfg *apiConfig) handleGetScheduledChirps(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userUuid, err := cfg.authenticatedUser(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	pending, err := cfg.dbQueries.ListPendingChirps(r.Context(), userUuid)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	chirps := make([]chirp, 0, len(pending))
+	for _, c := range pending {
+		chirps = append(chirps, chirpFromDB(c))
+	}
+	respondWithJSON(w, http.StatusOK, chirps)
+}
+
+// handleRescheduleChirp moves a pending chirp to a new publish time.
+func (cfg *apiConfig) handleRescheduleChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		PublishAt *time.Time `json:"publish_at"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userUuid, err := cfg.activeUser(r)
+	if err != nil {
+		respondWithActiveUserError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Something went wrong")
+		return
+	}
+	if params.PublishAt == nil {
+		respondWithError(w, http.StatusBadRequest, "publish_at is required")
+		return
+	}
+	publishAt, err := parsePublishAt(params.PublishAt, time.Now())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var rescheduled database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		rescheduled, err = q.RescheduleChirp(r.Context(), database.RescheduleChirpParams{
+			ID:        parsedChirpID,
+			UserID:    userUuid,
+			PublishAt: publishAt,
+		})
+		if err != nil {
+			return err
+		}
+		// Hashtag rows carry the chirp's created_at for the tag timelines.
+		if err := q.DeleteChirpHashtags(r.Context(), rescheduled.ID); err != nil {
+			return err
+		}
+		return saveChirpHashtags(r.Context(), q, rescheduled)
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Scheduled chirp not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, chirpFromDB(rescheduled))
+}
+
+// handleCancelScheduledChirp drops a pending chirp outright; nobody has seen
+// it yet, so there is nothing to keep around for a restore.
+func (cfg *apiConfig) handleCancelScheduledChirp(w http.ResponseWriter, r *http.Request) {
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userUuid, err := cfg.activeUser(r)
+	if err != nil {
+		respondWithActiveUserError(w, err)
+		return
+	}
+
+	deleted, err := cfg.dbQueries.DeletePendingChirp(r.Context(), database.DeletePendingChirpParams{
+		ID:     parsedChirpID,
+		UserID: userUuid,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Scheduled chirp not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
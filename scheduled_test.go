@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePublishAt(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	inZone := now.Add(time.Hour).In(time.FixedZone("UTC+2", 2*60*60))
+
+	publishAt, err := parsePublishAt(nil, now)
+	if err != nil || publishAt.Valid {
+		t.Errorf("Expected no publish time for nil, got %v, %v", publishAt, err)
+	}
+
+	publishAt, err = parsePublishAt(&inZone, now)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !publishAt.Valid || publishAt.Time.Location() != time.UTC || !publishAt.Time.Equal(inZone) {
+		t.Errorf("Expected %v in UTC, got %v", inZone, publishAt.Time)
+	}
+
+	past := now.Add(-time.Minute)
+	if _, err := parsePublishAt(&past, now); err != errPublishAtPast {
+		t.Errorf("Expected %v, got %v", errPublishAtPast, err)
+	}
+	tooFar := now.Add(maxScheduleAhead + time.Hour)
+	if _, err := parsePublishAt(&tooFar, now); err != errPublishAtTooFar {
+		t.Errorf("Expected %v, got %v", errPublishAtTooFar, err)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/Chirpy/internal/pagination"
+	"github.com/Chirpy/internal/search"
+	"github.com/google/uuid"
+)
+
+// handleSearchChirps serves GET /api/chirps/search. Results are ranked by
+// relevance unless sort=recent is given; only recency ordering can be paged
+// with a cursor, relevance returns the best `limit` matches.
+func (cfg *apiConfig) handleSearchChirps(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	query := r.URL.Query()
+
+	tsQuery, err := search.BuildTSQuery(query.Get("q"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	authorIDs, err := parseAuthorIDs(query)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	viewer, err := cfg.optionalUser(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var chirps []database.Chirp
+	sortParam := strings.ToLower(query.Get("sort"))
+	switch sortParam {
+	case "", "relevance":
+		if query.Get("cursor") != "" {
+			respondWithError(w, http.StatusBadRequest, "cursor is only supported with sort=recent")
+			return
+		}
+		chirps, err = cfg.dbQueries.SearchChirpsByRank(r.Context(), database.SearchChirpsByRankParams{
+			ViewerID:  viewer,
+			Query:     tsQuery,
+			AuthorIds: authorIDs,
+			RowLimit:  int32(limit),
+		})
+	case "recent":
+		searchParams := database.SearchChirpsByRecencyParams{
+			ViewerID:  viewer,
+			Query:     tsQuery,
+			AuthorIds: authorIDs,
+			RowLimit:  int32(limit + 1),
+		}
+		cursorParam := query.Get("cursor")
+		if cursorParam != "" {
+			cursor, err := pagination.DecodeCursor(cursorParam)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			searchParams.AfterCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+			searchParams.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+		}
+		chirps, err = cfg.dbQueries.SearchChirpsByRecency(r.Context(), searchParams)
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid sort parameter")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	page, err := cfg.newChirpPage(r.Context(), viewer, chirps, limit)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxContentWarningLength = 100
+
+// How a user wants sensitive chirps in GET /api/chirps. Anonymous viewers
+// get sensitiveMediaCollapse.
+const (
+	sensitiveMediaCollapse = "collapse"
+	sensitiveMediaExpand   = "expand"
+	sensitiveMediaHide     = "hide"
+)
+
+var (
+	errContentWarningLength = fmt.Errorf("Content warnings can be at most %d characters", maxContentWarningLength)
+	errInvalidSensitiveMode = errors.New("sensitive_media must be collapse, expand or hide")
+)
+
+type userPreferences struct {
+	SensitiveMedia string `json:"sensitive_media"`
+}
+
+// prepareContentWarning trims and checks a content warning. Any chirp behind
+// a warning counts as sensitive, whatever the author passed for the flag.
+func prepareContentWarning(warning string, sensitive bool) (string, bool, error) {
+	warning = strings.TrimSpace(warning)
+	if utf8.RuneCountInString(warning) > maxContentWarningLength {
+		return "", false, errContentWarningLength
+	}
+	return warning, sensitive || warning != "", nil
+}
+
+// sensitiveMediaPreference looks up the viewer's setting.
+func (cfg *apiConfig) sensitiveMediaPreference(ctx context.Context, viewer uuid.NullUUID) (string, error) {
+	if !viewer.Valid {
+		return sensitiveMediaCollapse, nil
+	}
+	user, err := cfg.dbQueries.GetUserById(ctx, viewer.UUID)
+	if err != nil {
+		return "", err
+	}
+	return user.SensitiveMedia, nil
+}
+
+// applySensitivePreference drops or collapses sensitive chirps for a viewer.
+// List queries already leave hidden chirps out so pages stay full; dropping
+// them here as well covers chirps added afterwards, like a pinned chirp.
+// Viewers always see their own chirps.
+func applySensitivePreference(chirps []chirp, preference string, viewer uuid.NullUUID) []chirp {
+	if preference == sensitiveMediaExpand {
+		return chirps
+	}
+	kept := make([]chirp, 0, len(chirps))
+	for _, c := range chirps {
+		own := viewer.Valid && c.UserID == viewer.UUID
+		if preference == sensitiveMediaHide && !own && (c.Sensitive || (c.RechirpOf != nil && c.RechirpOf.Sensitive)) {
+			continue
+		}
+		c.Collapsed = c.Sensitive
+		if c.RechirpOf != nil {
+			c.RechirpOf.Collapsed = c.RechirpOf.Sensitive
+		}
+		if c.QuotedChirp != nil {
+			c.QuotedChirp.Collapsed = c.QuotedChirp.Sensitive
+		}
+		kept = append(kept, c)
+	}
+	return kept
+}
+
+func (cfg *apiConfig) handleGetPreferences(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userUuid, err := cfg.authenticatedUser(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	user, err := cfg.dbQueries.GetUserById(r.Context(), userUuid)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, userPreferences{SensitiveMedia: user.SensitiveMedia})
+}
+
+func (cfg *apiConfig) handleUpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userUuid, err := cfg.activeUser(r)
+	if err != nil {
+		respondWithActiveUserError(w, err)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := userPreferences{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Something went wrong")
+		return
+	}
+	switch params.SensitiveMedia {
+	case sensitiveMediaCollapse, sensitiveMediaExpand, sensitiveMediaHide:
+	default:
+		respondWithError(w, http.StatusBadRequest, errInvalidSensitiveMode.Error())
+		return
+	}
+
+	user, err := cfg.dbQueries.UpdateSensitiveMediaPreference(r.Context(), database.UpdateSensitiveMediaPreferenceParams{
+		ID:             userUuid,
+		SensitiveMedia: params.SensitiveMedia,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, userPreferences{SensitiveMedia: user.SensitiveMedia})
+}
+
+// handleSetChirpSensitive lets moderators mark any chirp sensitive, or clear
+// the mark.
+func (cfg *apiConfig) handleSetChirpSensitive(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Sensitive bool `json:"sensitive"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, ok := cfg.actorWithRole(r, roleModerator); !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Something went wrong")
+		return
+	}
+
+	updatedChirp, err := cfg.dbQueries.SetChirpSensitive(r.Context(), database.SetChirpSensitiveParams{
+		ID:        parsedChirpID,
+		Sensitive: params.Sensitive,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, chirpFromDB(updatedChirp))
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPrepareContentWarning(t *testing.T) {
+	tests := map[string]struct {
+		warning       string
+		sensitive     bool
+		wantWarning   string
+		wantSensitive bool
+		wantErr       error
+	}{
+		"none":            {"", false, "", false, nil},
+		"flag only":       {"", true, "", true, nil},
+		"warning implies": {"spoilers", false, "spoilers", true, nil},
+		"trimmed":         {"  spoilers  ", false, "spoilers", true, nil},
+		"blank warning":   {"   ", false, "", false, nil},
+		"too long":        {strings.Repeat("a", maxContentWarningLength+1), false, "", false, errContentWarningLength},
+	}
+	for name, tt := range tests {
+		warning, sensitive, err := prepareContentWarning(tt.warning, tt.sensitive)
+		if warning != tt.wantWarning || sensitive != tt.wantSensitive || err != tt.wantErr {
+			t.Errorf("%s: expected %q, %v, %v, got %q, %v, %v", name, tt.wantWarning, tt.wantSensitive, tt.wantErr, warning, sensitive, err)
+		}
+	}
+}
+
+func TestApplySensitivePreference(t *testing.T) {
+	viewerID, otherID := uuid.New(), uuid.New()
+	viewer := uuid.NullUUID{UUID: viewerID, Valid: true}
+	chirps := func() []chirp {
+		return []chirp{
+			{UserID: otherID},
+			{UserID: otherID, Sensitive: true},
+			{UserID: viewerID, Sensitive: true},
+			{UserID: otherID, RechirpOf: &chirp{UserID: otherID, Sensitive: true}},
+			{UserID: otherID, QuotedChirp: &chirp{UserID: otherID, Sensitive: true}},
+		}
+	}
+
+	got := applySensitivePreference(chirps(), sensitiveMediaExpand, viewer)
+	for i, c := range got {
+		if c.Collapsed {
+			t.Errorf("expand: chirp %d collapsed", i)
+		}
+	}
+
+	got = applySensitivePreference(chirps(), sensitiveMediaCollapse, viewer)
+	if len(got) != 5 {
+		t.Fatalf("collapse: expected 5 chirps, got %d", len(got))
+	}
+	if got[0].Collapsed || !got[1].Collapsed || !got[2].Collapsed || !got[3].RechirpOf.Collapsed || !got[4].QuotedChirp.Collapsed {
+		t.Errorf("collapse: expected only sensitive chirps collapsed")
+	}
+
+	got = applySensitivePreference(chirps(), sensitiveMediaHide, viewer)
+	if len(got) != 3 {
+		t.Fatalf("hide: expected 3 chirps, got %d", len(got))
+	}
+	if got[1].UserID != viewerID || !got[1].Collapsed {
+		t.Errorf("hide: expected the viewer's own sensitive chirp to stay collapsed")
+	}
+	if !got[2].QuotedChirp.Collapsed {
+		t.Errorf("hide: expected a quoted sensitive chirp to be collapsed")
+	}
+
+	got = applySensitivePreference(chirps(), sensitiveMediaHide, uuid.NullUUID{})
+	if len(got) != 2 {
+		t.Errorf("hide anonymous: expected 2 chirps, got %d", len(got))
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultChirpDeleteGrace = 7 * 24 * time.Hour
+	chirpPurgeInterval      = time.Hour
+)
+
+// adjustReferenceCounts keeps the counters on the chirps that c replies to,
+// rechirps or quotes in step when c is deleted or restored.
+func adjustReferenceCounts(ctx context.Context, q *database.Queries, c database.Chirp, restored bool) error {
+	if c.ParentChirpID.Valid {
+		adjust := q.DecrementReplyCount
+		if restored {
+			adjust = q.IncrementReplyCount
+		}
+		if err := adjust(ctx, c.ParentChirpID.UUID); err != nil {
+			return err
+		}
+	}
+	if c.RechirpOfID.Valid {
+		adjust := q.DecrementRechirpCount
+		if restored {
+			adjust = q.IncrementRechirpCount
+		}
+		if err := adjust(ctx, c.RechirpOfID.UUID); err != nil {
+			return err
+		}
+	}
+	if c.QuoteOfID.Valid {
+		adjust := q.DecrementQuoteCount
+		if restored {
+			adjust = q.IncrementQuoteCount
+		}
+		return adjust(ctx, c.QuoteOfID.UUID)
+	}
+	return nil
+}
+
+// handleChirpRestore undoes a delete while the chirp is still inside its
+// grace period.
+func (cfg *apiConfig) handleChirpRestore(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userUuid, err := cfg.activeUser(r)
+	if err != nil {
+		respondWithActiveUserError(w, err)
+		return
+	}
+
+	deletedChirp, err := cfg.dbQueries.GetDeletedChirpById(r.Context(), parsedChirpID)
+	if err != nil || deletedChirp.UserID != userUuid {
+		respondWithError(w, http.StatusNotFound, "Chirp not found")
+		return
+	}
+	if time.Since(deletedChirp.DeletedAt.Time) > cfg.chirpDeleteGrace {
+		respondWithError(w, http.StatusGone, "Restore period has passed")
+		return
+	}
+
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		restoreParams := database.RestoreChirpParams{ID: parsedChirpID, UserID: userUuid}
+		restored, err := q.RestoreChirp(r.Context(), restoreParams)
+		if err != nil || restored == 0 {
+			return err
+		}
+		return adjustReferenceCounts(r.Context(), q, deletedChirp, true)
+	})
+	// A deleted rechirp cannot come back once the user has rechirped the
+	// same chirp again.
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "You already rechirped this chirp")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	viewer := uuid.NullUUID{UUID: userUuid, Valid: true}
+	restoredChirp, err := cfg.dbQueries.GetChirpById(r.Context(), database.GetChirpByIdParams{ID: parsedChirpID, ViewerID: viewer})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	returnChirp := chirpFromDB(restoredChirp)
+	err = cfg.hydrateChirps(r.Context(), viewer, []*chirp{&returnChirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, returnChirp)
+}
+
+// purgeDeletedChirps permanently removes chirps whose grace period has run
+// out. Chirps that still have replies are reduced to tombstones until the
+// replies are gone too, and chirps hidden by a moderator are kept as
+// evidence for their reports.
+func (cfg *apiConfig) purgeDeletedChirps(ctx context.Context) error {
+	deletedBefore := time.Now().UTC().Add(-cfg.chirpDeleteGrace)
+	return cfg.withTx(ctx, func(q *database.Queries) error {
+		tombstoned, err := q.TombstoneExpiredChirps(ctx, deletedBefore)
+		if err != nil {
+			return err
+		}
+		for _, chirpID := range tombstoned {
+			if err := q.DeleteChirpHashtags(ctx, chirpID); err != nil {
+				return err
+			}
+			if err := q.DeleteChirpRevisions(ctx, chirpID); err != nil {
+				return err
+			}
+		}
+		// Purging a reply can leave its tombstoned parent with no replies, so
+		// repeat until a whole chain of them is gone.
+		for {
+			purged, err := q.PurgeExpiredChirps(ctx, deletedBefore)
+			if err != nil || purged == 0 {
+				return err
+			}
+		}
+	})
+}
+
+// runChirpPurger calls purgeDeletedChirps and purgeOrphanedMedia every
+// interval until ctx is done.
+func (cfg *apiConfig) runChirpPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := cfg.purgeDeletedChirps(ctx); err != nil {
+				log.Printf("purging deleted chirps: %v", err)
+			}
+			if err := cfg.purgeOrphanedMedia(ctx); err != nil {
+				log.Printf("purging orphaned media: %v", err)
+			}
+		}
+	}
+}
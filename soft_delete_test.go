@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Chirpy/internal/database"
+)
+
+func TestPurgeDeletedChirps_KeepsHiddenChirps(t *testing.T) {
+	cfg := newTestConfig(t)
+	ctx := context.Background()
+	// Everything deleted counts as expired, whatever the server's time zone.
+	cfg.chirpDeleteGrace = -48 * time.Hour
+
+	author, _ := createTestUser(t, cfg, roleUser)
+	deleted := createTestChirp(t, cfg, author.ID, "gone soon", visibilityPublic)
+	hidden := createTestChirp(t, cfg, author.ID, "evidence", visibilityPublic)
+	if _, err := cfg.dbQueries.DeleteChirp(ctx, database.DeleteChirpParams{ID: deleted.ID, UserID: author.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.dbQueries.HideChirp(ctx, hidden.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cfg.purgeDeletedChirps(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.dbQueries.GetChirpForModeration(ctx, deleted.ID); err != sql.ErrNoRows {
+		t.Errorf("expected the deleted chirp to be purged, got %v", err)
+	}
+	kept, err := cfg.dbQueries.GetChirpForModeration(ctx, hidden.ID)
+	if err != nil {
+		t.Fatalf("expected the hidden chirp to be kept, got %v", err)
+	}
+	if kept.Body != "evidence" || kept.TombstonedAt.Valid {
+		t.Errorf("expected the hidden chirp to keep its body, got %+v", kept)
+	}
+}
+
+func TestPurgeDeletedChirps_WaitsForReports(t *testing.T) {
+	cfg := newTestConfig(t)
+	ctx := context.Background()
+	cfg.chirpDeleteGrace = -48 * time.Hour
+
+	author, _ := createTestUser(t, cfg, roleUser)
+	reporter, _ := createTestUser(t, cfg, roleUser)
+	reported := createTestChirp(t, cfg, author.ID, "deleted to dodge a report", visibilityPublic)
+	if _, err := cfg.dbQueries.CreateReport(ctx, database.CreateReportParams{
+		ChirpID:    reported.ID,
+		ReporterID: reporter.ID,
+		Reason:     "harassment",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.dbQueries.DeleteChirp(ctx, database.DeleteChirpParams{ID: reported.ID, UserID: author.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cfg.purgeDeletedChirps(ctx); err != nil {
+		t.Fatal(err)
+	}
+	kept, err := cfg.dbQueries.GetChirpForModeration(ctx, reported.ID)
+	if err != nil {
+		t.Fatalf("expected a chirp with an open report to be kept, got %v", err)
+	}
+	if kept.Body != "deleted to dodge a report" {
+		t.Errorf("expected the reported chirp to keep its body, got %q", kept.Body)
+	}
+}
@@ -0,0 +1,15 @@
+-- name: ListBannedWords :many
+select * from banned_words order by word;
+
+-- name: CreateBannedWord :one
+insert into banned_words (created_at, updated_at, word, action)
+values (now(), now(), $1, $2)
+returning *;
+
+-- name: UpdateBannedWord :one
+update banned_words set word = $2, action = $3, updated_at = now()
+where id = $1
+returning *;
+
+-- name: DeleteBannedWord :execrows
+delete from banned_words where id = $1;
@@ -0,0 +1,31 @@
+-- name: CreateBookmark :execrows
+insert into bookmarks (user_id, chirp_id, created_at)
+values ($1, $2, now())
+on conflict do nothing;
+
+-- name: DeleteBookmark :execrows
+delete from bookmarks where user_id = $1 and chirp_id = $2;
+
+-- name: ListBookmarkedChirps :many
+-- Bookmarks of chirps that were deleted or can no longer be seen are skipped
+-- rather than removed, so they come back if the chirp becomes visible again.
+select sqlc.embed(chirps), bookmarks.created_at as bookmarked_at from bookmarks
+join chirps on chirps.id = bookmarks.chirp_id
+where bookmarks.user_id = @user_id
+  and chirps.deleted_at is null
+  and (chirps.publish_at is null or chirps.publish_at <= now())
+  and chirp_visible_to(chirps.user_id, chirps.visibility, chirps.entities, chirps.flagged_at, @user_id::uuid)
+  and (chirps.rechirp_of_id is null or exists (
+        select 1 from chirps original
+        where original.id = chirps.rechirp_of_id
+          and original.deleted_at is null
+          and (original.publish_at is null or original.publish_at <= now())
+          and chirp_visible_to(original.user_id, original.visibility, original.entities, original.flagged_at, @user_id::uuid)))
+  and (sqlc.narg('after_created_at')::timestamp is null
+       or (bookmarks.created_at, bookmarks.chirp_id) < (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_chirp_id')::uuid))
+order by bookmarks.created_at desc, bookmarks.chirp_id desc
+limit sqlc.arg('row_limit');
+
+-- name: GetBookmarkedChirpIds :many
+select chirp_id from bookmarks
+where user_id = @user_id and chirp_id = any(@chirp_ids::uuid[]);
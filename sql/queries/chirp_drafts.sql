@@ -0,0 +1,20 @@
+-- name: CreateChirpDraft :one
+insert into chirp_drafts (created_at, updated_at, user_id, body, parent_chirp_id)
+values (now(), now(), $1, $2, $3)
+returning *;
+
+-- name: GetChirpDraft :one
+select * from chirp_drafts where id = $1 and user_id = $2;
+
+-- name: ListChirpDrafts :many
+select * from chirp_drafts
+where user_id = $1
+order by updated_at desc, id desc;
+
+-- name: UpdateChirpDraft :one
+update chirp_drafts set body = $3, parent_chirp_id = $4, updated_at = now()
+where id = $1 and user_id = $2
+returning *;
+
+-- name: DeleteChirpDraft :execrows
+delete from chirp_drafts where id = $1 and user_id = $2;
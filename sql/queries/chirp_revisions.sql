@@ -0,0 +1,11 @@
+-- name: CreateChirpRevision :exec
+insert into chirp_revisions (chirp_id, body, entities, created_at, replaced_at)
+values ($1, $2, $3, $4, now());
+
+-- name: ListChirpRevisions :many
+select * from chirp_revisions
+where chirp_id = $1
+order by replaced_at desc;
+
+-- name: DeleteChirpRevisions :exec
+delete from chirp_revisions where chirp_id = $1;
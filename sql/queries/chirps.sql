@@ -1,16 +1,290 @@
 -- name: CreateChirp :one
-insert into chirps (created_at, updated_at, body, user_id)
-values (now(),now(), $1, $2)
+insert into chirps (created_at, updated_at, body, user_id, parent_chirp_id, root_chirp_id, quote_of_id, entities, publish_at, visibility, content_warning, sensitive)
+values (coalesce($7, now()),now(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 returning *;
 
--- name: GetChirps :many
-select * from chirps order by created_at asc;
+-- name: CreateRechirp :one
+insert into chirps (created_at, updated_at, body, user_id, rechirp_of_id)
+values (now(), now(), '', $1, $2)
+returning *;
+
+-- name: DeleteRechirp :execrows
+-- A rechirp already deleted through DELETE /api/chirps/{chirpID} has had its
+-- count adjusted, so only live ones match.
+delete from chirps where user_id = $1 and rechirp_of_id = $2 and deleted_at is null;
 
 -- name: GetChirpById :one
-select * from chirps where id = $1;
+-- Chirps the viewer may not see come back as sql.ErrNoRows, the same as
+-- chirps that do not exist.
+select * from chirps
+where id = @id
+  and deleted_at is null
+  and (publish_at is null or publish_at <= now())
+  and chirp_visible_to(user_id, visibility, entities, flagged_at, sqlc.narg('viewer_id')::uuid);
+
+-- name: GetPinnedChirp :one
+select chirps.* from users
+join chirps on chirps.id = users.pinned_chirp_id
+where users.id = @user_id
+  and chirps.deleted_at is null
+  and (chirps.publish_at is null or chirps.publish_at <= now())
+  and chirp_visible_to(chirps.user_id, chirps.visibility, chirps.entities, chirps.flagged_at, sqlc.narg('viewer_id')::uuid);
+
+-- name: GetChirpByIdForUpdate :one
+select * from chirps where id = $1 and deleted_at is null for update;
+
+-- name: GetDeletedChirpById :one
+select * from chirps where id = $1 and deleted_at is not null and tombstoned_at is null and hidden_at is null;
+
+-- name: UpdateChirpBody :one
+update chirps set body = $2, entities = $3, edited_at = now(), updated_at = now()
+where id = $1
+returning *;
+
+-- name: GetChirpsByIds :many
+select * from chirps
+where id = any(@ids::uuid[])
+  and deleted_at is null
+  and (publish_at is null or publish_at <= now())
+  and chirp_visible_to(user_id, visibility, entities, flagged_at, sqlc.narg('viewer_id')::uuid);
+
+-- name: DeleteChirp :execrows
+-- Deletes are soft until PurgeExpiredChirps runs, so owners can restore them.
+update chirps set deleted_at = now(), updated_at = now()
+where id = $1 and user_id = $2 and deleted_at is null;
+
+-- name: RestoreChirp :execrows
+update chirps set deleted_at = null, updated_at = now()
+where id = $1 and user_id = $2 and deleted_at is not null and tombstoned_at is null and hidden_at is null;
+
+-- name: TombstoneExpiredChirps :many
+-- Expired chirps that still have replies are scrubbed instead of deleted so
+-- the thread keeps its shape. Hidden chirps, and chirps with reports still
+-- waiting on a moderator, are kept whole here and in PurgeExpiredChirps.
+update chirps set body = '', entities = '{}', tombstoned_at = now(), updated_at = now()
+where deleted_at < @deleted_before::timestamp
+  and tombstoned_at is null
+  and hidden_at is null
+  and not exists (select 1 from reports where reports.chirp_id = chirps.id and reports.status <> 'resolved')
+  and exists (select 1 from chirps replies where replies.parent_chirp_id = chirps.id)
+returning id;
+
+-- name: PurgeExpiredChirps :execrows
+-- Tombstones go too once the last of their replies has been purged. Hidden
+-- chirps are never purged, and reported ones not until every report is
+-- resolved, since the reports would cascade away with them.
+delete from chirps
+where deleted_at < @deleted_before::timestamp
+  and hidden_at is null
+  and not exists (select 1 from reports where reports.chirp_id = chirps.id and reports.status <> 'resolved')
+  and not exists (select 1 from chirps replies where replies.parent_chirp_id = chirps.id);
+
+-- name: IncrementReplyCount :exec
+update chirps set reply_count = reply_count + 1 where id = $1;
+
+-- name: DecrementReplyCount :exec
+update chirps set reply_count = greatest(reply_count - 1, 0) where id = $1;
+
+-- name: IncrementRechirpCount :exec
+update chirps set rechirp_count = rechirp_count + 1 where id = $1;
+
+-- name: DecrementRechirpCount :exec
+update chirps set rechirp_count = greatest(rechirp_count - 1, 0) where id = $1;
+
+-- name: IncrementQuoteCount :exec
+update chirps set quote_count = quote_count + 1 where id = $1;
+
+-- name: DecrementQuoteCount :exec
+update chirps set quote_count = greatest(quote_count - 1, 0) where id = $1;
+
+-- name: ListReplies :many
+-- Deleted replies stay as placeholders while anything still replies to them.
+-- That is checked directly rather than through reply_count, which deletes
+-- lower down the thread also decrement.
+select * from chirps
+where parent_chirp_id = @parent_chirp_id
+  and (deleted_at is null or exists (select 1 from chirps below where below.parent_chirp_id = chirps.id))
+  and (publish_at is null or publish_at <= now())
+  and chirp_visible_to(user_id, visibility, entities, flagged_at, sqlc.narg('viewer_id')::uuid)
+  and (sqlc.narg('after_created_at')::timestamp is null
+       or (created_at, id) > (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+order by created_at asc, id asc
+limit sqlc.arg('row_limit');
+
+-- name: ListRepliesForParents :many
+-- Returns at most per_parent_limit replies for each parent, oldest first.
+select * from chirps
+where id in (
+    select ranked.id from (
+        select replies.id,
+               row_number() over (partition by replies.parent_chirp_id order by replies.created_at, replies.id) as reply_rank
+        from chirps replies
+        where replies.parent_chirp_id = any(@parent_ids::uuid[])
+          and (replies.deleted_at is null or exists (select 1 from chirps below where below.parent_chirp_id = replies.id))
+          and (replies.publish_at is null or replies.publish_at <= now())
+          and chirp_visible_to(replies.user_id, replies.visibility, replies.entities, replies.flagged_at, sqlc.narg('viewer_id')::uuid)
+    ) ranked
+    where ranked.reply_rank <= sqlc.arg('per_parent_limit')::bigint
+)
+order by parent_chirp_id, created_at asc, id asc;
+
+-- name: ListChirpsAsc :many
+-- Each sort direction gets its own query so Postgres can walk the
+-- (created_at, id) index forwards or backwards. Rechirps are skipped while
+-- the viewer cannot see the original, which for a hidden original is for
+-- good.
+select * from chirps
+where deleted_at is null
+  and (publish_at is null or publish_at <= now())
+  and chirp_visible_to(user_id, visibility, entities, flagged_at, sqlc.narg('viewer_id')::uuid)
+  and (chirps.rechirp_of_id is null or exists (
+        select 1 from chirps original
+        where original.id = chirps.rechirp_of_id
+          and original.deleted_at is null
+          and (original.publish_at is null or original.publish_at <= now())
+          and chirp_visible_to(original.user_id, original.visibility, original.entities, original.flagged_at, sqlc.narg('viewer_id')::uuid)))
+  and (coalesce(cardinality(@author_ids::uuid[]), 0) = 0 or user_id = any(@author_ids::uuid[]))
+  and (sqlc.narg('since')::timestamp is null or created_at >= sqlc.narg('since')::timestamp)
+  and (sqlc.narg('until')::timestamp is null or created_at < sqlc.narg('until')::timestamp)
+  and (not @hide_sensitive::bool
+       or not (sensitive or exists (select 1 from chirps original where original.id = chirps.rechirp_of_id and original.sensitive))
+       or user_id = sqlc.narg('viewer_id')::uuid)
+  and (sqlc.narg('after_created_at')::timestamp is null
+       or (created_at, id) > (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+order by created_at asc, id asc
+limit sqlc.arg('row_limit');
+
+-- name: ListChirpsDesc :many
+select * from chirps
+where deleted_at is null
+  and (publish_at is null or publish_at <= now())
+  and chirp_visible_to(user_id, visibility, entities, flagged_at, sqlc.narg('viewer_id')::uuid)
+  and (chirps.rechirp_of_id is null or exists (
+        select 1 from chirps original
+        where original.id = chirps.rechirp_of_id
+          and original.deleted_at is null
+          and (original.publish_at is null or original.publish_at <= now())
+          and chirp_visible_to(original.user_id, original.visibility, original.entities, original.flagged_at, sqlc.narg('viewer_id')::uuid)))
+  and (coalesce(cardinality(@author_ids::uuid[]), 0) = 0 or user_id = any(@author_ids::uuid[]))
+  and (sqlc.narg('since')::timestamp is null or created_at >= sqlc.narg('since')::timestamp)
+  and (sqlc.narg('until')::timestamp is null or created_at < sqlc.narg('until')::timestamp)
+  and (not @hide_sensitive::bool
+       or not (sensitive or exists (select 1 from chirps original where original.id = chirps.rechirp_of_id and original.sensitive))
+       or user_id = sqlc.narg('viewer_id')::uuid)
+  and (sqlc.narg('after_created_at')::timestamp is null
+       or (created_at, id) < (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+order by created_at desc, id desc
+limit sqlc.arg('row_limit');
+
+-- name: SearchChirpsByRank :many
+select * from chirps
+where deleted_at is null
+  and (publish_at is null or publish_at <= now())
+  and chirp_visible_to(user_id, visibility, entities, flagged_at, sqlc.narg('viewer_id')::uuid)
+  and (chirps.rechirp_of_id is null or exists (
+        select 1 from chirps original
+        where original.id = chirps.rechirp_of_id
+          and original.deleted_at is null
+          and (original.publish_at is null or original.publish_at <= now())
+          and chirp_visible_to(original.user_id, original.visibility, original.entities, original.flagged_at, sqlc.narg('viewer_id')::uuid)))
+  and search_vector @@ to_tsquery('english', @query)
+  and (coalesce(cardinality(@author_ids::uuid[]), 0) = 0 or user_id = any(@author_ids::uuid[]))
+order by ts_rank_cd(search_vector, to_tsquery('english', @query)) desc, created_at desc, id desc
+limit sqlc.arg('row_limit');
+
+-- name: SearchChirpsByRecency :many
+select * from chirps
+where deleted_at is null
+  and (publish_at is null or publish_at <= now())
+  and chirp_visible_to(user_id, visibility, entities, flagged_at, sqlc.narg('viewer_id')::uuid)
+  and (chirps.rechirp_of_id is null or exists (
+        select 1 from chirps original
+        where original.id = chirps.rechirp_of_id
+          and original.deleted_at is null
+          and (original.publish_at is null or original.publish_at <= now())
+          and chirp_visible_to(original.user_id, original.visibility, original.entities, original.flagged_at, sqlc.narg('viewer_id')::uuid)))
+  and search_vector @@ to_tsquery('english', @query)
+  and (coalesce(cardinality(@author_ids::uuid[]), 0) = 0 or user_id = any(@author_ids::uuid[]))
+  and (sqlc.narg('after_created_at')::timestamp is null
+       or (created_at, id) < (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+order by created_at desc, id desc
+limit sqlc.arg('row_limit');
+
+-- name: ListPendingChirps :many
+select * from chirps
+where user_id = $1 and deleted_at is null and publish_at > now()
+order by publish_at asc, id asc;
+
+-- name: RescheduleChirp :one
+update chirps set publish_at = $3, created_at = $3, updated_at = now()
+where id = $1 and user_id = $2 and deleted_at is null and publish_at > now()
+returning *;
+
+-- name: DeletePendingChirp :execrows
+delete from chirps
+where id = $1 and user_id = $2 and deleted_at is null and publish_at > now();
+
+-- name: SetChirpSensitive :one
+update chirps set sensitive = $2
+where id = $1 and deleted_at is null
+returning *;
+
+-- name: AddChirpViews :exec
+-- Applies a batch of view counts; ids and counts are parallel arrays.
+update chirps set view_count = chirps.view_count + views.count
+from unnest(@ids::uuid[], @counts::bigint[]) as views(id, count)
+where chirps.id = views.id;
+
+-- name: FlagChirp :exec
+-- Keeps the first flag time so re-flagging does not move a chirp in the
+-- review list, and adds any reasons the chirp was not already held for.
+update chirps
+set flag_reason = coalesce((
+        select string_agg(reason, '; ' order by first_seen)
+        from (
+            select reason, min(ord) as first_seen
+            from unnest(string_to_array(
+                case when flagged_at is null or flag_reason = '' then $2::text
+                     else flag_reason || '; ' || $2::text end,
+                '; ')) with ordinality as held(reason, ord)
+            group by reason
+        ) reasons
+    ), ''),
+    flagged_at = coalesce(flagged_at, now())
+where id = $1;
+
+-- name: ClearChirpFlag :exec
+-- Approves a held chirp, taking it off the review list.
+update chirps set flagged_at = null, flag_reason = '' where id = $1;
+
+-- name: ListRecentChirpBodies :many
+-- Original chirps, replies and quotes an author posted since a time, for
+-- duplicate detection.
+select id, body, parent_chirp_id from chirps
+where user_id = $1
+  and created_at >= $2
+  and rechirp_of_id is null
+  and deleted_at is null;
+
+-- name: ListFlaggedChirps :many
+select * from chirps
+where flagged_at is not null
+  and deleted_at is null
+  and (sqlc.narg('after_flagged_at')::timestamp is null
+       or (flagged_at, id) < (sqlc.narg('after_flagged_at')::timestamp, sqlc.narg('after_id')::uuid))
+order by flagged_at desc, id desc
+limit sqlc.arg('row_limit');
+
+-- name: GetChirpParentId :one
+select parent_chirp_id from chirps where id = $1;
 
--- name: DeleteChirp :exec
-delete from chirps where id = $1 and user_id = $2;
+-- name: GetChirpForModeration :one
+-- A chirp in any state, locked while a moderator acts on it.
+select * from chirps where id = $1 for update;
 
--- name: GetChirpsByUserId :many
-select * from chirps where user_id = $1 order by created_at asc;
\ No newline at end of file
+-- name: HideChirp :exec
+-- Hidden chirps are deleted for everyone, and their authors cannot restore
+-- them. A chirp the author already deleted keeps its delete time.
+update chirps
+set hidden_at = coalesce(hidden_at, now()), deleted_at = coalesce(deleted_at, now()), updated_at = now()
+where id = $1;
@@ -0,0 +1,7 @@
+-- name: CreateFollow :execrows
+insert into follows (follower_id, followee_id, created_at)
+values ($1, $2, now())
+on conflict do nothing;
+
+-- name: DeleteFollow :execrows
+delete from follows where follower_id = $1 and followee_id = $2;
@@ -0,0 +1,49 @@
+-- name: CreateHashtags :exec
+insert into hashtags (created_at, tag)
+select now(), unnest(@tags::text[])
+on conflict (tag) do nothing;
+
+-- name: CreateChirpHashtags :exec
+-- created_at copies the chirp's timestamp so tag timelines can be paged
+-- without touching the chirps table.
+insert into chirp_hashtags (chirp_id, hashtag_id, created_at)
+select @chirp_id::uuid, hashtags.id, @created_at::timestamp
+from hashtags
+where hashtags.tag = any(@tags::text[])
+on conflict do nothing;
+
+-- name: DeleteChirpHashtags :exec
+delete from chirp_hashtags where chirp_id = $1;
+
+-- name: ListChirpsByHashtag :many
+select chirps.* from chirp_hashtags
+join hashtags on hashtags.id = chirp_hashtags.hashtag_id
+join chirps on chirps.id = chirp_hashtags.chirp_id
+where hashtags.tag = @tag
+  and chirps.deleted_at is null
+  and (chirps.publish_at is null or chirps.publish_at <= now())
+  and chirp_visible_to(chirps.user_id, chirps.visibility, chirps.entities, chirps.flagged_at, sqlc.narg('viewer_id')::uuid)
+  and (chirps.rechirp_of_id is null or exists (
+        select 1 from chirps original
+        where original.id = chirps.rechirp_of_id
+          and original.deleted_at is null
+          and (original.publish_at is null or original.publish_at <= now())
+          and chirp_visible_to(original.user_id, original.visibility, original.entities, original.flagged_at, sqlc.narg('viewer_id')::uuid)))
+  and (sqlc.narg('after_created_at')::timestamp is null
+       or (chirp_hashtags.created_at, chirp_hashtags.chirp_id) < (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+order by chirp_hashtags.created_at desc, chirp_hashtags.chirp_id desc
+limit sqlc.arg('row_limit');
+
+-- name: ListTrendingHashtags :many
+select hashtags.tag, count(*) as chirp_count
+from chirp_hashtags
+join hashtags on hashtags.id = chirp_hashtags.hashtag_id
+join chirps on chirps.id = chirp_hashtags.chirp_id
+where chirp_hashtags.created_at >= @since::timestamp
+  and chirps.deleted_at is null
+  and (chirps.publish_at is null or chirps.publish_at <= now())
+  and chirps.visibility = 'public'
+  and chirps.flagged_at is null
+group by hashtags.tag
+order by chirp_count desc, hashtags.tag asc
+limit sqlc.arg('row_limit');
@@ -0,0 +1,25 @@
+-- name: CreateLike :execrows
+insert into chirp_likes (chirp_id, user_id, created_at)
+values ($1, $2, now())
+on conflict do nothing;
+
+-- name: DeleteLike :execrows
+delete from chirp_likes where chirp_id = $1 and user_id = $2;
+
+-- name: IncrementLikeCount :exec
+update chirps set like_count = like_count + 1 where id = $1;
+
+-- name: DecrementLikeCount :exec
+update chirps set like_count = greatest(like_count - 1, 0) where id = $1;
+
+-- name: ListChirpLikes :many
+select * from chirp_likes
+where chirp_id = @chirp_id
+  and (sqlc.narg('after_created_at')::timestamp is null
+       or (created_at, user_id) < (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_user_id')::uuid))
+order by created_at desc, user_id desc
+limit sqlc.arg('row_limit');
+
+-- name: GetLikedChirpIds :many
+select chirp_id from chirp_likes
+where user_id = @user_id and chirp_id = any(@chirp_ids::uuid[]);
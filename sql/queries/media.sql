@@ -0,0 +1,24 @@
+-- name: CreateMedia :one
+insert into media (id, created_at, user_id, content_type, storage_key, thumbnail_key, width, height, alt_text)
+values ($1, now(), $2, $3, $4, $5, $6, $7, $8)
+returning *;
+
+-- name: AttachMedia :execrows
+-- Only the uploader's unattached media can be claimed; callers compare the
+-- row count with the number of ids they passed.
+update media
+set chirp_id = @chirp_id::uuid, position = ids.position
+from unnest(@ids::uuid[]) with ordinality as ids(id, position)
+where media.id = ids.id
+  and media.user_id = @user_id
+  and media.chirp_id is null;
+
+-- name: ListMediaForChirps :many
+select * from media
+where chirp_id = any(@chirp_ids::uuid[])
+order by chirp_id, position;
+
+-- name: DeleteOrphanedMedia :many
+delete from media
+where chirp_id is null and created_at < @created_before::timestamp
+returning storage_key, thumbnail_key;
@@ -0,0 +1,38 @@
+-- name: CreatePoll :exec
+insert into polls (chirp_id, created_at, closes_at)
+values ($1, now(), $2);
+
+-- name: CreatePollOptions :exec
+insert into poll_options (chirp_id, position, label)
+select @chirp_id::uuid, options.position, options.label
+from unnest(@labels::text[]) with ordinality as options(label, position);
+
+-- name: GetPoll :one
+select * from polls where chirp_id = $1;
+
+-- name: ListPollsForChirps :many
+select * from polls where chirp_id = any(@chirp_ids::uuid[]);
+
+-- name: ListPollOptionsForChirps :many
+select * from poll_options
+where chirp_id = any(@chirp_ids::uuid[])
+order by chirp_id, position;
+
+-- name: CreatePollVote :execrows
+-- Inserts nothing when the user has already voted or the option is not part
+-- of an open poll on this chirp.
+insert into poll_votes (chirp_id, user_id, option_id, created_at)
+select poll_options.chirp_id, @user_id::uuid, poll_options.id, now()
+from poll_options
+join polls on polls.chirp_id = poll_options.chirp_id
+where poll_options.id = @option_id
+  and poll_options.chirp_id = @chirp_id
+  and polls.closes_at > now()
+on conflict do nothing;
+
+-- name: IncrementPollOptionVoteCount :exec
+update poll_options set vote_count = vote_count + 1 where id = $1;
+
+-- name: GetPollVotesByUser :many
+select chirp_id, option_id from poll_votes
+where user_id = @user_id and chirp_id = any(@chirp_ids::uuid[]);
@@ -12,4 +12,9 @@ where token = $1;
 -- name: RevokeRefreshToken :exec
 UPDATE refresh_tokens set revoked_at = now(),
     updated_at = now()
-WHERE token = $1;
\ No newline at end of file
+WHERE token = $1;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens set revoked_at = now(),
+    updated_at = now()
+WHERE user_id = $1 and revoked_at is null;
@@ -0,0 +1,55 @@
+-- name: CreateReport :one
+insert into reports (created_at, updated_at, chirp_id, reporter_id, reason, details)
+values (now(), now(), $1, $2, $3, $4)
+returning *;
+
+-- name: GetReportById :one
+select * from reports where id = $1;
+
+-- name: ListReports :many
+-- Oldest first, so the queue is worked in the order reports came in.
+select * from reports
+where status = sqlc.arg('status')
+  and (sqlc.narg('after_created_at')::timestamp is null
+       or (created_at, id) > (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+order by created_at, id
+limit sqlc.arg('row_limit');
+
+-- name: ClaimReport :one
+-- A moderator can claim an unresolved report nobody holds, renew their own
+-- claim, or take over a claim older than stale_before.
+update reports
+set status = 'claimed', claimed_by = sqlc.narg('moderator_id'), claimed_at = now(), updated_at = now()
+where id = sqlc.arg('id')
+  and status <> 'resolved'
+  and (claimed_at is null
+       or claimed_by is not distinct from sqlc.narg('moderator_id')
+       or claimed_at < sqlc.arg('stale_before')::timestamp)
+returning *;
+
+-- name: ResolveReport :one
+-- Only the moderator holding the claim can resolve a report.
+update reports
+set status = 'resolved', resolution = sqlc.arg('resolution')::text, resolved_at = now(), updated_at = now()
+where id = sqlc.arg('id')
+  and status = 'claimed'
+  and claimed_by is not distinct from sqlc.narg('moderator_id')
+returning *;
+
+-- name: ResolveChirpReports :many
+-- Closes the remaining reports on a chirp once it has been dealt with.
+update reports
+set status = 'resolved', resolution = sqlc.arg('resolution')::text, resolved_at = now(), updated_at = now()
+where chirp_id = sqlc.arg('chirp_id') and status <> 'resolved'
+returning id;
+
+-- name: CreateModerationLogEntry :exec
+insert into moderation_log (created_at, actor_id, action, report_id, chirp_id, user_id, note)
+values (now(), $1, $2, $3, $4, $5, $6);
+
+-- name: ListModerationLog :many
+select * from moderation_log
+where sqlc.narg('after_created_at')::timestamp is null
+   or (created_at, id) < (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid)
+order by created_at desc, id desc
+limit sqlc.arg('row_limit');
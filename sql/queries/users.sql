@@ -1,7 +1,7 @@
 -- name: CreateUser :one
-INSERT INTO users (created_at, updated_at, email, hashed_password)
+INSERT INTO users (created_at, updated_at, email, hashed_password, username)
 VALUES (
-    now(), now(), $1, $2
+    now(), now(), $1, $2, $3
 )
 RETURNING *;
 
@@ -11,13 +11,18 @@ Select * from users where id = $1;
 -- name: GetUserByEmail :one
 Select * from users where email = $1;
 
+-- name: GetUserIdsByUsernames :many
+Select id, username from users where username = any(@usernames::text[]);
+
 -- name: DeleteUsers :exec
 DELETE FROM users;
 
 -- name: UpdateUserById :one
-Update users set email = $2, hashed_password = $3,
+-- The username is left alone when none is given.
+Update users set email = @email, hashed_password = @hashed_password,
+                 username = coalesce(sqlc.narg('username')::text, username),
                  updated_at = now()
-where id = $1
+where id = @id
 returning *;
 
 -- name: UpgradeUserById :one
@@ -25,3 +30,25 @@ Update users set is_chirpy_red = true, updated_at = now()
 where id = $1
 returning *;
 
+
+-- name: PinChirp :exec
+Update users set pinned_chirp_id = $2, updated_at = now()
+where id = $1;
+
+-- name: UnpinChirp :execrows
+Update users set pinned_chirp_id = null, updated_at = now()
+where id = $1 and pinned_chirp_id = $2;
+
+-- name: UpdateSensitiveMediaPreference :one
+Update users set sensitive_media = $2, updated_at = now()
+where id = $1
+returning *;
+
+-- name: SuspendUser :exec
+Update users set suspended_at = coalesce(suspended_at, now()), updated_at = now()
+where id = $1;
+
+-- name: SetUserRole :one
+Update users set role = $2, updated_at = now()
+where id = $1
+returning *;
@@ -0,0 +1,7 @@
+-- +goose Up
+create index idx_chirps_created_at_id on chirps (created_at, id);
+create index idx_chirps_user_id_created_at_id on chirps (user_id, created_at, id);
+
+-- +goose Down
+drop index idx_chirps_user_id_created_at_id;
+drop index idx_chirps_created_at_id;
@@ -0,0 +1,8 @@
+-- +goose Up
+alter table chirps add column search_vector tsvector
+    generated always as (to_tsvector('english', body)) stored;
+create index idx_chirps_search_vector on chirps using gin (search_vector);
+
+-- +goose Down
+drop index idx_chirps_search_vector;
+alter table chirps drop column search_vector;
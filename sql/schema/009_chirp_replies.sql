@@ -0,0 +1,15 @@
+-- +goose Up
+alter table chirps add column parent_chirp_id UUID references chirps(id) on delete set null;
+alter table chirps add column root_chirp_id UUID references chirps(id) on delete set null;
+alter table chirps add column reply_count integer not null default 0;
+alter table chirps add column tombstoned_at timestamp;
+create index idx_chirps_parent_chirp_id on chirps (parent_chirp_id, created_at, id);
+create index idx_chirps_root_chirp_id on chirps (root_chirp_id);
+
+-- +goose Down
+drop index idx_chirps_root_chirp_id;
+drop index idx_chirps_parent_chirp_id;
+alter table chirps drop column tombstoned_at;
+alter table chirps drop column reply_count;
+alter table chirps drop column root_chirp_id;
+alter table chirps drop column parent_chirp_id;
@@ -0,0 +1,16 @@
+-- +goose Up
+alter table chirps add column rechirp_of_id UUID references chirps(id) on delete cascade;
+alter table chirps add column quote_of_id UUID references chirps(id) on delete set null;
+alter table chirps add column rechirp_count integer not null default 0;
+alter table chirps add column quote_count integer not null default 0;
+create unique index idx_chirps_user_id_rechirp_of_id on chirps (user_id, rechirp_of_id)
+    where rechirp_of_id is not null;
+create index idx_chirps_quote_of_id on chirps (quote_of_id);
+
+-- +goose Down
+drop index idx_chirps_quote_of_id;
+drop index idx_chirps_user_id_rechirp_of_id;
+alter table chirps drop column quote_count;
+alter table chirps drop column rechirp_count;
+alter table chirps drop column quote_of_id;
+alter table chirps drop column rechirp_of_id;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE chirp_likes (
+    chirp_id UUID not null references chirps(id) on delete cascade,
+    user_id UUID not null references users(id) on delete cascade,
+    created_at timestamp not null,
+    primary key (chirp_id, user_id)
+);
+create index idx_chirp_likes_chirp_id_created_at on chirp_likes (chirp_id, created_at, user_id);
+alter table chirps add column like_count integer not null default 0;
+
+-- +goose Down
+alter table chirps drop column like_count;
+DROP TABLE chirp_likes;
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE hashtags (
+    id UUID DEFAULT gen_random_uuid() primary key,
+    created_at timestamp not null,
+    tag text not null unique
+);
+
+CREATE TABLE chirp_hashtags (
+    chirp_id UUID not null references chirps(id) on delete cascade,
+    hashtag_id UUID not null references hashtags(id) on delete cascade,
+    created_at timestamp not null,
+    primary key (chirp_id, hashtag_id)
+);
+create index idx_chirp_hashtags_hashtag_id_created_at on chirp_hashtags (hashtag_id, created_at, chirp_id);
+create index idx_chirp_hashtags_created_at on chirp_hashtags (created_at);
+
+-- +goose Down
+DROP TABLE chirp_hashtags;
+DROP TABLE hashtags;
@@ -0,0 +1,5 @@
+-- +goose Up
+alter table chirps add column entities jsonb not null default '{}';
+
+-- +goose Down
+alter table chirps drop column entities;
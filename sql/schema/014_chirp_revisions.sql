@@ -0,0 +1,16 @@
+-- +goose Up
+alter table chirps add column edited_at timestamp;
+
+CREATE TABLE chirp_revisions (
+    id UUID DEFAULT gen_random_uuid() primary key,
+    chirp_id UUID not null references chirps(id) on delete cascade,
+    body text not null,
+    entities jsonb not null default '{}',
+    created_at timestamp not null,
+    replaced_at timestamp not null
+);
+create index idx_chirp_revisions_chirp_id on chirp_revisions (chirp_id, replaced_at);
+
+-- +goose Down
+DROP TABLE chirp_revisions;
+alter table chirps drop column edited_at;
@@ -0,0 +1,8 @@
+-- +goose Up
+alter table chirps add column deleted_at timestamp;
+update chirps set deleted_at = tombstoned_at where tombstoned_at is not null;
+create index idx_chirps_deleted_at on chirps (deleted_at) where deleted_at is not null;
+
+-- +goose Down
+drop index idx_chirps_deleted_at;
+alter table chirps drop column deleted_at;
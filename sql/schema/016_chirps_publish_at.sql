@@ -0,0 +1,9 @@
+-- +goose Up
+-- Scheduled chirps take their publish time as created_at so they slot into
+-- timelines where readers expect them once they go live.
+alter table chirps add column publish_at timestamp;
+create index idx_chirps_user_id_publish_at on chirps (user_id, publish_at) where publish_at is not null;
+
+-- +goose Down
+drop index idx_chirps_user_id_publish_at;
+alter table chirps drop column publish_at;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE chirp_drafts (
+    id UUID DEFAULT gen_random_uuid() primary key,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    user_id UUID not null references users(id) on delete cascade,
+    body text not null,
+    parent_chirp_id UUID
+);
+create index idx_chirp_drafts_user_id on chirp_drafts (user_id, updated_at);
+
+-- +goose Down
+DROP TABLE chirp_drafts;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Uploads start out unattached; chirp_id is filled in when a chirp claims
+-- them and cleared again if that chirp is purged, so the orphan sweep picks
+-- the files up either way.
+CREATE TABLE media (
+    id UUID primary key,
+    created_at timestamp not null,
+    user_id UUID not null references users(id) on delete cascade,
+    chirp_id UUID references chirps(id) on delete set null,
+    position int not null default 0,
+    content_type text not null,
+    storage_key text not null,
+    thumbnail_key text not null,
+    width int not null,
+    height int not null,
+    alt_text text not null default ''
+);
+create index idx_media_chirp_id on media (chirp_id, position);
+create index idx_media_unattached on media (created_at) where chirp_id is null;
+
+-- +goose Down
+DROP TABLE media;
@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE polls (
+    chirp_id UUID primary key references chirps(id) on delete cascade,
+    created_at timestamp not null,
+    closes_at timestamp not null
+);
+
+CREATE TABLE poll_options (
+    id UUID DEFAULT gen_random_uuid() primary key,
+    chirp_id UUID not null references polls(chirp_id) on delete cascade,
+    position int not null,
+    label text not null,
+    vote_count int not null default 0,
+    unique (chirp_id, position)
+);
+
+CREATE TABLE poll_votes (
+    chirp_id UUID not null references polls(chirp_id) on delete cascade,
+    user_id UUID not null references users(id) on delete cascade,
+    option_id UUID not null references poll_options(id) on delete cascade,
+    created_at timestamp not null,
+    primary key (chirp_id, user_id)
+);
+
+-- +goose Down
+DROP TABLE poll_votes;
+DROP TABLE poll_options;
+DROP TABLE polls;
@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE follows (
+    follower_id UUID not null references users(id) on delete cascade,
+    followee_id UUID not null references users(id) on delete cascade,
+    created_at timestamp not null,
+    primary key (follower_id, followee_id)
+);
+
+alter table chirps add column visibility text not null default 'public'
+    check (visibility in ('public', 'followers', 'mentioned'));
+
+-- chirp_visible_to is the single definition of who may read a chirp. Authors
+-- always see their own chirps; 'mentioned' chirps are readable by the users
+-- resolved in the chirp's entities. A null viewer only sees public chirps.
+-- +goose StatementBegin
+create function chirp_visible_to(author_id uuid, visibility text, entities jsonb, viewer_id uuid)
+returns boolean
+language sql stable
+as $$
+    select coalesce(visibility = 'public'
+        or author_id = viewer_id
+        or (visibility = 'followers' and exists (
+                select 1 from follows
+                where follows.follower_id = viewer_id and follows.followee_id = author_id))
+        or (visibility = 'mentioned'
+            and entities->'mentions' @> jsonb_build_array(jsonb_build_object('user_id', viewer_id))), false);
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+drop function chirp_visible_to(uuid, text, jsonb, uuid);
+alter table chirps drop column visibility;
+DROP TABLE follows;
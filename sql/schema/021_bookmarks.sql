@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE bookmarks (
+    user_id UUID not null references users(id) on delete cascade,
+    chirp_id UUID not null references chirps(id) on delete cascade,
+    created_at timestamp not null,
+    primary key (user_id, chirp_id)
+);
+create index idx_bookmarks_user_id_created_at on bookmarks (user_id, created_at, chirp_id);
+
+-- +goose Down
+DROP TABLE bookmarks;
@@ -0,0 +1,5 @@
+-- +goose Up
+alter table users add column pinned_chirp_id UUID references chirps(id) on delete set null;
+
+-- +goose Down
+alter table users drop column pinned_chirp_id;
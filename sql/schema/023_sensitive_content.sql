@@ -0,0 +1,10 @@
+-- +goose Up
+alter table chirps add column content_warning text not null default '';
+alter table chirps add column sensitive boolean not null default false;
+alter table users add column sensitive_media text not null default 'collapse'
+    check (sensitive_media in ('collapse', 'expand', 'hide'));
+
+-- +goose Down
+alter table users drop column sensitive_media;
+alter table chirps drop column sensitive;
+alter table chirps drop column content_warning;
@@ -0,0 +1,5 @@
+-- +goose Up
+alter table chirps add column view_count bigint not null default 0;
+
+-- +goose Down
+alter table chirps drop column view_count;
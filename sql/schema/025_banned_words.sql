@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE banned_words (
+    id UUID DEFAULT gen_random_uuid() primary key,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    word text not null unique,
+    action text not null default 'mask'
+        check (action in ('mask', 'reject', 'flag'))
+);
+insert into banned_words (created_at, updated_at, word)
+values (now(), now(), 'kerfuffle'), (now(), now(), 'sharbert'), (now(), now(), 'fornax');
+
+alter table chirps add column flagged_at timestamp;
+create index idx_chirps_flagged_at on chirps (flagged_at, id) where flagged_at is not null;
+
+-- +goose Down
+drop index idx_chirps_flagged_at;
+alter table chirps drop column flagged_at;
+DROP TABLE banned_words;
@@ -0,0 +1,5 @@
+-- +goose Up
+alter table chirps add column flag_reason text not null default '';
+
+-- +goose Down
+alter table chirps drop column flag_reason;
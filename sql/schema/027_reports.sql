@@ -0,0 +1,59 @@
+-- +goose Up
+CREATE TABLE reports (
+    id UUID DEFAULT gen_random_uuid() primary key,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    chirp_id UUID not null references chirps(id) on delete cascade,
+    reporter_id UUID not null references users(id) on delete cascade,
+    reason text not null
+        check (reason in ('spam', 'harassment', 'hate', 'violence', 'sexual', 'self_harm', 'misinformation', 'other')),
+    details text not null default '',
+    status text not null default 'open'
+        check (status in ('open', 'claimed', 'resolved')),
+    claimed_by UUID references users(id) on delete set null,
+    claimed_at timestamp,
+    resolution text
+        check (resolution in ('dismiss', 'hide_chirp', 'suspend_author')),
+    resolved_at timestamp,
+    unique (chirp_id, reporter_id)
+);
+create index idx_reports_status_created_at on reports (status, created_at, id);
+
+-- moderation_log records every report and every moderator action. Ids are
+-- kept without foreign keys so entries outlive the chirps and users they
+-- mention, and a trigger refuses to change or remove a row.
+CREATE TABLE moderation_log (
+    id UUID DEFAULT gen_random_uuid() primary key,
+    created_at timestamp not null,
+    actor_id UUID,
+    action text not null,
+    report_id UUID,
+    chirp_id UUID,
+    user_id UUID,
+    note text not null default ''
+);
+create index idx_moderation_log_created_at on moderation_log (created_at, id);
+
+-- +goose StatementBegin
+create function moderation_log_append_only() returns trigger
+language plpgsql
+as $$
+begin
+    raise exception 'moderation_log is append-only';
+end;
+$$;
+-- +goose StatementEnd
+create trigger moderation_log_append_only
+    before update or delete on moderation_log
+    for each row execute function moderation_log_append_only();
+
+alter table chirps add column hidden_at timestamp;
+alter table users add column suspended_at timestamp;
+
+-- +goose Down
+alter table users drop column suspended_at;
+alter table chirps drop column hidden_at;
+drop trigger moderation_log_append_only on moderation_log;
+drop function moderation_log_append_only();
+DROP TABLE moderation_log;
+DROP TABLE reports;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- A deleted rechirp waits out the grace period like any other chirp, and
+-- should not stop the user rechirping the same chirp again meanwhile.
+drop index idx_chirps_user_id_rechirp_of_id;
+create unique index idx_chirps_user_id_rechirp_of_id on chirps (user_id, rechirp_of_id)
+    where rechirp_of_id is not null and deleted_at is null;
+
+-- +goose Down
+drop index idx_chirps_user_id_rechirp_of_id;
+create unique index idx_chirps_user_id_rechirp_of_id on chirps (user_id, rechirp_of_id)
+    where rechirp_of_id is not null;
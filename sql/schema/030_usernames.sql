@@ -0,0 +1,12 @@
+-- +goose Up
+-- Usernames are the public handle chirps mention users by, so mentions never
+-- need to reveal or look up anyone's email.
+alter table users add column username text;
+update users set username = 'user_' || substr(replace(id::text, '-', ''), 1, 12);
+alter table users alter column username set not null;
+alter table users add constraint users_username_format check (username ~ '^[a-z0-9_]{3,30}$');
+create unique index idx_users_username on users (username);
+
+-- +goose Down
+drop index idx_users_username;
+alter table users drop column username;
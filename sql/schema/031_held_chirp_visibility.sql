@@ -0,0 +1,38 @@
+-- +goose Up
+-- Chirps held for review are visible only to their authors until a moderator
+-- approves them, so chirp_visible_to now takes the chirp's flagged_at.
+drop function chirp_visible_to(uuid, text, jsonb, uuid);
+
+-- +goose StatementBegin
+create function chirp_visible_to(author_id uuid, visibility text, entities jsonb, flagged_at timestamp, viewer_id uuid)
+returns boolean
+language sql stable
+as $$
+    select coalesce(author_id = viewer_id
+        or (flagged_at is null
+            and (visibility = 'public'
+                or (visibility = 'followers' and exists (
+                        select 1 from follows
+                        where follows.follower_id = viewer_id and follows.followee_id = author_id))
+                or (visibility = 'mentioned'
+                    and entities->'mentions' @> jsonb_build_array(jsonb_build_object('user_id', viewer_id))))), false);
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+drop function chirp_visible_to(uuid, text, jsonb, timestamp, uuid);
+
+-- +goose StatementBegin
+create function chirp_visible_to(author_id uuid, visibility text, entities jsonb, viewer_id uuid)
+returns boolean
+language sql stable
+as $$
+    select coalesce(visibility = 'public'
+        or author_id = viewer_id
+        or (visibility = 'followers' and exists (
+                select 1 from follows
+                where follows.follower_id = viewer_id and follows.followee_id = author_id))
+        or (visibility = 'mentioned'
+            and entities->'mentions' @> jsonb_build_array(jsonb_build_object('user_id', viewer_id))), false);
+$$;
+-- +goose StatementEnd
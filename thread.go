@@ -0,0 +1,173 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultThreadDepth = 3
+	maxThreadDepth     = 10
+	// maxThreadNodes stops a wide thread from fanning out into an unbounded
+	// response. Nodes left unexpanded still report their reply_count.
+	maxThreadNodes = 1000
+)
+
+type threadNode struct {
+	chirp
+	Replies    []*threadNode `json:"replies"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+func newThreadNode(dbChirp database.Chirp) *threadNode {
+	return &threadNode{chirp: chirpFromDB(dbChirp), Replies: []*threadNode{}}
+}
+
+// chirps appends every chirp in the subtree to dst, depth first.
+func (node *threadNode) chirps(dst []*chirp) []*chirp {
+	dst = append(dst, &node.chirp)
+	for _, reply := range node.Replies {
+		dst = reply.chirps(dst)
+	}
+	return dst
+}
+
+func parseThreadDepth(value string) (int, error) {
+	if value == "" {
+		return defaultThreadDepth, nil
+	}
+	depth, err := strconv.Atoi(value)
+	if err != nil || depth < 0 || depth > maxThreadDepth {
+		return 0, errors.New("depth must be between 0 and " + strconv.Itoa(maxThreadDepth))
+	}
+	return depth, nil
+}
+
+// handleGetThread returns the reply tree below a chirp. `limit` applies to
+// every level; `cursor` pages the direct replies of the requested chirp, and
+// deeper levels are paged by requesting the thread of that reply.
+func (cfg *apiConfig) handleGetThread(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	query := r.URL.Query()
+
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	depth, err := parseThreadDepth(query.Get("depth"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	viewer, err := cfg.optionalUser(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	repliesParams := database.ListRepliesParams{ParentChirpID: parsedChirpID, ViewerID: viewer, RowLimit: int32(limit + 1)}
+	cursorParam := query.Get("cursor")
+	if cursorParam != "" {
+		cursor, err := pagination.DecodeCursor(cursorParam)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		repliesParams.AfterCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		repliesParams.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	dbChirp, err := cfg.dbQueries.GetChirpById(r.Context(), database.GetChirpByIdParams{ID: parsedChirpID, ViewerID: viewer})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	root := newThreadNode(dbChirp)
+	if depth == 0 {
+		err = cfg.hydrateChirps(r.Context(), viewer, root.chirps(nil))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+			return
+		}
+		respondWithJSON(w, http.StatusOK, root)
+		return
+	}
+
+	replies, err := cfg.dbQueries.ListReplies(r.Context(), repliesParams)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	level := attachReplies(root, replies, limit)
+	nodeCount := 1 + len(level)
+
+	for d := 2; d <= depth && len(level) > 0 && nodeCount < maxThreadNodes; d++ {
+		// Every node is asked for, not just those with a reply_count: a
+		// deleted reply further down lowers the count of the chirp above it
+		// while its own replies are still shown.
+		parents := make(map[uuid.UUID]*threadNode)
+		parentIDs := make([]uuid.UUID, 0, len(level))
+		for _, node := range level {
+			parents[node.ID] = node
+			parentIDs = append(parentIDs, node.ID)
+		}
+
+		replies, err := cfg.dbQueries.ListRepliesForParents(r.Context(), database.ListRepliesForParentsParams{
+			ParentIds:      parentIDs,
+			ViewerID:       viewer,
+			PerParentLimit: int64(limit + 1),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+			return
+		}
+
+		byParent := make(map[uuid.UUID][]database.Chirp)
+		for _, reply := range replies {
+			byParent[reply.ParentChirpID.UUID] = append(byParent[reply.ParentChirpID.UUID], reply)
+		}
+		var next []*threadNode
+		for _, parentID := range parentIDs {
+			children := attachReplies(parents[parentID], byParent[parentID], limit)
+			next = append(next, children...)
+		}
+		level = next
+		nodeCount += len(level)
+	}
+
+	err = cfg.hydrateChirps(r.Context(), viewer, root.chirps(nil))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, root)
+}
+
+// attachReplies hangs up to limit replies under parent and records a cursor
+// when the query returned the extra look-ahead row.
+func attachReplies(parent *threadNode, replies []database.Chirp, limit int) []*threadNode {
+	if len(replies) > limit {
+		replies = replies[:limit]
+		last := replies[limit-1]
+		parent.NextCursor = pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	for _, reply := range replies {
+		parent.Replies = append(parent.Replies, newThreadNode(reply))
+	}
+	return parent.Replies
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	viewDedupeWindow  = 30 * time.Minute
+	viewFlushInterval = 10 * time.Second
+)
+
+// viewerKey identifies a viewer for de-duplication: the user when signed in,
+// otherwise the client address.
+func viewerKey(r *http.Request, viewer uuid.NullUUID) string {
+	if viewer.Valid {
+		return "user:" + viewer.UUID.String()
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "addr:" + host
+}
+
+// recordViews counts the chirps served to a viewer. A rechirp counts as a
+// view of its original, and authors looking at their own chirps are not
+// counted. Counts only reach the database on the next flush, so view_count
+// lags by up to viewFlushInterval.
+func (cfg *apiConfig) recordViews(r *http.Request, viewer uuid.NullUUID, chirps []chirp) {
+	key := viewerKey(r, viewer)
+	for _, c := range chirps {
+		if c.RechirpOf != nil {
+			c = *c.RechirpOf
+		} else if c.RechirpOfID != nil {
+			// The original is hidden from this viewer.
+			continue
+		}
+		if c.Deleted || (viewer.Valid && c.UserID == viewer.UUID) {
+			continue
+		}
+		cfg.views.Record(c.ID, key)
+	}
+}
+
+// flushViews writes the views counted since the last flush in one statement.
+func (cfg *apiConfig) flushViews(ctx context.Context) error {
+	counts := cfg.views.Flush()
+	if len(counts) == 0 {
+		return nil
+	}
+	params := database.AddChirpViewsParams{
+		Ids:    make([]uuid.UUID, 0, len(counts)),
+		Counts: make([]int64, 0, len(counts)),
+	}
+	for chirpID, n := range counts {
+		params.Ids = append(params.Ids, chirpID)
+		params.Counts = append(params.Counts, n)
+	}
+	if err := cfg.dbQueries.AddChirpViews(ctx, params); err != nil {
+		cfg.views.Restore(counts)
+		return err
+	}
+	return nil
+}
+
+// runViewFlusher calls flushViews every interval until ctx is done.
+func (cfg *apiConfig) runViewFlusher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := cfg.flushViews(ctx); err != nil {
+				log.Printf("flushing chirp views: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Who may read a chirp is decided in SQL by chirp_visible_to; these are the
+// values it understands.
+const (
+	visibilityPublic    = "public"
+	visibilityFollowers = "followers"
+	visibilityMentioned = "mentioned"
+)
+
+var errInvalidVisibility = errors.New("visibility must be public, followers or mentioned")
+
+// parseVisibility defaults an empty value to public.
+func parseVisibility(value string) (string, error) {
+	switch value {
+	case "":
+		return visibilityPublic, nil
+	case visibilityPublic, visibilityFollowers, visibilityMentioned:
+		return value, nil
+	}
+	return "", errInvalidVisibility
+}
+
+func (cfg *apiConfig) handleFollowUser(w http.ResponseWriter, r *http.Request) {
+	cfg.setFollow(w, r, true)
+}
+
+func (cfg *apiConfig) handleUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	cfg.setFollow(w, r, false)
+}
+
+// setFollow makes the caller follow or unfollow a user. Both directions are
+// idempotent.
+func (cfg *apiConfig) setFollow(w http.ResponseWriter, r *http.Request, follow bool) {
+	parsedUserID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userUuid, err := cfg.activeUser(r)
+	if err != nil {
+		respondWithActiveUserError(w, err)
+		return
+	}
+	if parsedUserID == userUuid {
+		respondWithError(w, http.StatusBadRequest, "You cannot follow yourself")
+		return
+	}
+
+	if follow {
+		if _, err := cfg.dbQueries.GetUserById(r.Context(), parsedUserID); err != nil {
+			if err == sql.ErrNoRows {
+				respondWithError(w, http.StatusNotFound, "User not found")
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+			return
+		}
+		_, err = cfg.dbQueries.CreateFollow(r.Context(), database.CreateFollowParams{
+			FollowerID: userUuid,
+			FolloweeID: parsedUserID,
+		})
+	} else {
+		_, err = cfg.dbQueries.DeleteFollow(r.Context(), database.DeleteFollowParams{
+			FollowerID: userUuid,
+			FolloweeID: parsedUserID,
+		})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
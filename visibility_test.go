@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestParseVisibility(t *testing.T) {
+	tests := map[string]struct {
+		want    string
+		wantErr error
+	}{
+		"":          {visibilityPublic, nil},
+		"public":    {visibilityPublic, nil},
+		"followers": {visibilityFollowers, nil},
+		"mentioned": {visibilityMentioned, nil},
+		"Public":    {"", errInvalidVisibility},
+		"private":   {"", errInvalidVisibility},
+	}
+	for value, tt := range tests {
+		got, err := parseVisibility(value)
+		if got != tt.want || err != tt.wantErr {
+			t.Errorf("%q: expected %q, %v, got %q, %v", value, tt.want, tt.wantErr, got, err)
+		}
+	}
+}